@@ -0,0 +1,253 @@
+package grpc
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fidesy/sdk/common/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type (
+	AccessLogConfig struct {
+		// Share of logged successful requests from 0 to 1, failed ones are always logged. Default 1
+		SampleRate float64
+		// Overrides by full method name or service wildcard ("/users_service.UserService/*")
+		MethodSampleRates map[string]float64
+		// Full method names or service wildcards which are never logged. Default health checks
+		ExcludeMethods []string
+	}
+
+	requestIDKey struct{}
+)
+
+// WithAccessLog logs every completed request with its request id, method, peer, duration, status code,
+// messages size and trace id. The request id is taken from x-request-id metadata or generated, it is
+// returned in x-request-id response header and passed to outgoing calls.
+func WithAccessLog(config AccessLogConfig) ServerOption {
+	return func(s *Server) error {
+		if config.SampleRate == 0 {
+			config.SampleRate = 1
+		}
+
+		if config.ExcludeMethods == nil {
+			config.ExcludeMethods = []string{healthServicePolicyKey}
+		}
+
+		s.accessLog = &config
+		return nil
+	}
+}
+
+// RequestIDFromContext returns id of the request handled by the server, WithAccessLog is required.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+func accessLogInterceptor(config *AccessLogConfig) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx = withRequestID(ctx)
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		if config.shouldLog(info.FullMethod, err) {
+			logRequest(ctx, info.FullMethod, time.Since(start), err,
+				zap.Int("request_size", messageSize(req)),
+				zap.Int("response_size", messageSize(resp)),
+			)
+		}
+
+		return resp, err
+	}
+}
+
+func streamAccessLogInterceptor(config *AccessLogConfig) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx := withRequestID(stream.Context())
+
+		sizedStream := &sizedServerStream{
+			ServerStream: wrapServerStream(stream, ctx),
+		}
+
+		start := time.Now()
+		err := handler(srv, sizedStream)
+
+		if config.shouldLog(info.FullMethod, err) {
+			logRequest(ctx, info.FullMethod, time.Since(start), err,
+				zap.Int("request_size", sizedStream.received),
+				zap.Int("response_size", sizedStream.sent),
+				zap.Int("messages_received", sizedStream.receivedMessages),
+				zap.Int("messages_sent", sizedStream.sentMessages),
+			)
+		}
+
+		return err
+	}
+}
+
+// withRequestID puts the request id to ctx and outgoing metadata and sends it in response header.
+func withRequestID(ctx context.Context) context.Context {
+	requestID := ""
+	if values := metadata.ValueFromIncomingContext(ctx, requestIDHeader); len(values) > 0 {
+		requestID = values[0]
+	}
+
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+
+	if err := grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, requestID)); err != nil {
+		logger.Errorf("grpc.SetHeader: %v", err)
+	}
+
+	ctx = metadata.AppendToOutgoingContext(ctx, requestIDHeader, requestID)
+
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	_, _ = cryptorand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+func (c *AccessLogConfig) shouldLog(fullMethod string, err error) bool {
+	if slices.ContainsFunc(c.ExcludeMethods, func(pattern string) bool {
+		return matchMethod(pattern, fullMethod)
+	}) {
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	sampleRate := c.SampleRate
+	if rate, ok := c.MethodSampleRates[fullMethod]; ok {
+		sampleRate = rate
+	} else if i := strings.LastIndex(fullMethod, "/"); i > 0 {
+		if rate, ok := c.MethodSampleRates[fullMethod[:i]+"/*"]; ok {
+			sampleRate = rate
+		}
+	}
+
+	return sampleRate >= 1 || rand.Float64() < sampleRate
+}
+
+// matchMethod matches full method name by exact name or service wildcard.
+func matchMethod(pattern, fullMethod string) bool {
+	if service, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(fullMethod, service+"/")
+	}
+
+	return pattern == fullMethod
+}
+
+func logRequest(ctx context.Context, fullMethod string, duration time.Duration, err error, fields ...zap.Field) {
+	peerAddress := ""
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		peerAddress = p.Addr.String()
+	}
+
+	if address, ok := forwardedFor(ctx); ok {
+		peerAddress = address
+	}
+
+	fields = append([]zap.Field{
+		zap.String("request_id", RequestIDFromContext(ctx)),
+		zap.String("method", fullMethod),
+		zap.String("peer", peerAddress),
+		zap.Duration("duration", duration),
+		zap.String("status_code", status.Code(err).String()),
+		zap.String("trace_id", traceIDFromContext(ctx)),
+	}, fields...)
+
+	if err != nil {
+		fields = append(fields, zap.String("error", status.Convert(err).Message()))
+	}
+
+	logger.Info(fmt.Sprintf("%s %s", fullMethod, status.Code(err)), fields...)
+}
+
+func messageSize(message interface{}) int {
+	if message, ok := message.(proto.Message); ok {
+		return proto.Size(message)
+	}
+
+	return 0
+}
+
+// sizedServerStream counts messages and their size.
+type sizedServerStream struct {
+	grpc.ServerStream
+
+	received         int
+	sent             int
+	receivedMessages int
+	sentMessages     int
+}
+
+func (s *sizedServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent += messageSize(m)
+		s.sentMessages++
+	}
+
+	return err
+}
+
+func (s *sizedServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received += messageSize(m)
+		s.receivedMessages++
+	}
+
+	return err
+}
+
+// forwardedFor returns the client address of a gateway request.
+// Gateway requests come in-process, the gateway appends the remote address to x-forwarded-for,
+// so only the last entry is trusted: the preceding ones are sent by the client.
+// Other callers can't be trusted with x-forwarded-for at all.
+func forwardedFor(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil || p.Addr.Network() != gatewayNetwork {
+		return "", false
+	}
+
+	values := metadata.ValueFromIncomingContext(ctx, "x-forwarded-for")
+	if len(values) == 0 {
+		return "", false
+	}
+
+	value := values[len(values)-1]
+	address := strings.TrimSpace(value[strings.LastIndex(value, ",")+1:])
+
+	return address, address != ""
+}
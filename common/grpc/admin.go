@@ -0,0 +1,153 @@
+package grpc
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/fidesy/sdk/common/logger"
+)
+
+// Build info, set by
+// go build -ldflags "-X github.com/fidesy/sdk/common/grpc.Version=v1.2.3 -X github.com/fidesy/sdk/common/grpc.Commit=$(git rev-parse HEAD)"
+var (
+	Version = "unknown"
+	Commit  = ""
+)
+
+const adminTokenHeader = "X-Admin-Token"
+
+// WithAdminToken requires the token in X-Admin-Token or "Authorization: Bearer" header for /debug handlers
+// and handlers added by HandleAdmin. In single port mode /debug handlers are served only with the token.
+func WithAdminToken(token string) ServerOption {
+	return func(s *Server) error {
+		s.adminToken = token
+		return nil
+	}
+}
+
+// registerDebugHandlers mounts pprof, expvar, build info and effective server config.
+// Handlers are registered on the admin mux explicitly, but importing net/http/pprof and expvar
+// still registers /debug/pprof/ and /debug/vars on http.DefaultServeMux without the admin token,
+// so it must not be served by the process.
+func (s *Server) registerDebugHandlers(mux *http.ServeMux) {
+	if s.singlePort && s.adminToken == "" {
+		return
+	}
+
+	mux.Handle("/debug/pprof/", s.requireAdminToken(http.HandlerFunc(pprof.Index)))
+	mux.Handle("/debug/pprof/cmdline", s.requireAdminToken(http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("/debug/pprof/profile", s.requireAdminToken(http.HandlerFunc(pprof.Profile)))
+	mux.Handle("/debug/pprof/symbol", s.requireAdminToken(http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("/debug/pprof/trace", s.requireAdminToken(http.HandlerFunc(pprof.Trace)))
+	mux.Handle("/debug/vars", s.requireAdminToken(expvar.Handler()))
+	mux.Handle("/debug/build", s.requireAdminToken(http.HandlerFunc(buildInfoHandler)))
+	mux.Handle("/debug/config", s.requireAdminToken(http.HandlerFunc(s.configHandler)))
+}
+
+func (s *Server) requireAdminToken(next http.Handler) http.Handler {
+	if s.adminToken == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(adminTokenHeader)
+		if token == "" {
+			token, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			http.Error(w, "invalid admin token", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// buildInfo returns version and commit from ldflags, the commit falls back to vcs info of the binary.
+func buildInfo() map[string]string {
+	info := map[string]string{
+		"version":    Version,
+		"commit":     Commit,
+		"go_version": runtime.Version(),
+	}
+
+	if info["commit"] == "" {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			for _, setting := range bi.Settings {
+				if setting.Key == "vcs.revision" {
+					info["commit"] = setting.Value
+				}
+			}
+		}
+	}
+
+	return info
+}
+
+func buildInfoHandler(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, buildInfo())
+}
+
+// configHandler lists effective server options, secrets are not shown.
+func (s *Server) configHandler(w http.ResponseWriter, _ *http.Request) {
+	config := map[string]any{
+		"app_name":         appName,
+		"env":              os.Getenv("ENV"),
+		"build":            buildInfo(),
+		"port":             s.port,
+		"metrics_port":     s.metricsPort,
+		"proxy_port":       s.proxyPort,
+		"swagger_port":     s.swaggerPort,
+		"single_port":      s.singlePort,
+		"tls":              s.tlsConfig != nil,
+		"auth":             s.authenticator != nil,
+		"recovery":         !s.disableRecovery,
+		"validation":       !s.disableValidation,
+		"tracing":          loadTracer() != nil,
+		"shutdown_timeout": s.shutdownTimeout.String(),
+		"http_timeouts": map[string]string{
+			"read_header": s.httpTimeouts.ReadHeader.String(),
+			"read":        s.httpTimeouts.Read.String(),
+			"write":       s.httpTimeouts.Write.String(),
+			"idle":        s.httpTimeouts.Idle.String(),
+		},
+		"health_checks":       len(s.healthChecks),
+		"unary_interceptors":  len(s.unaryInterceptors),
+		"stream_interceptors": len(s.streamInterceptors),
+		"proxy_headers":       s.proxyHeaders,
+		"domain_name_service": domainNameServiceClient != nil,
+		"instance":            s.instanceDescription(),
+		"resolver_cache":      s.resolverCacheDir,
+		"admin_handlers":      len(s.adminHandlers),
+	}
+
+	if s.rateLimiter != nil {
+		config["rate_limit"] = s.rateLimiter.config
+	}
+
+	if s.cors != nil {
+		corsConfig := s.cors.config
+		corsConfig.AllowedOrigins = *s.cors.origins.Load()
+		config["cors"] = corsConfig
+	}
+
+	writeJSON(w, config)
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		logger.Errorf("json.Encode: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package grpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterDebugHandlers(t *testing.T) {
+	paths := []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/pprof/cmdline", "/debug/vars", "/debug/build"}
+
+	tests := []struct {
+		name       string
+		server     *Server
+		header     http.Header
+		wantStatus int
+	}{
+		{
+			name:       "without token",
+			server:     &Server{},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "token is required",
+			server:     &Server{adminToken: "secret"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "invalid token",
+			server:     &Server{adminToken: "secret"},
+			header:     http.Header{adminTokenHeader: {"invalid"}},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "token header",
+			server:     &Server{adminToken: "secret"},
+			header:     http.Header{adminTokenHeader: {"secret"}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "bearer token",
+			server:     &Server{adminToken: "secret"},
+			header:     http.Header{"Authorization": {"Bearer secret"}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "single port without token",
+			server:     &Server{singlePort: true},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			tt.server.registerDebugHandlers(mux)
+
+			for _, path := range paths {
+				r := httptest.NewRequest(http.MethodGet, path, nil)
+				r.Header = tt.header.Clone()
+				if r.Header == nil {
+					r.Header = http.Header{}
+				}
+
+				w := httptest.NewRecorder()
+				mux.ServeHTTP(w, r)
+
+				require.Equal(t, tt.wantStatus, w.Code, path)
+			}
+		})
+	}
+}
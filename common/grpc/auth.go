@@ -0,0 +1,99 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fidesy/sdk/common/grpc/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const healthServicePolicyKey = "/grpc.health.v1.Health/*"
+
+// WithAuth authenticates every call and authorizes it by the method policy,
+// the principal is available in handlers via auth.FromContext.
+// Health checks are public unless policies say otherwise.
+func WithAuth(authenticator auth.Authenticator, policies auth.Policies) ServerOption {
+	return func(s *Server) error {
+		s.authenticator = authenticator
+		s.authPolicies = make(auth.Policies, len(policies)+1)
+		s.authPolicies[healthServicePolicyKey] = auth.Public
+
+		for method, policy := range policies {
+			s.authPolicies[method] = policy
+		}
+
+		// api key header must reach the grpc server from the gateway
+		s.proxyHeaders = append(s.proxyHeaders, auth.APIKeyHeader)
+
+		return nil
+	}
+}
+
+func authInterceptor(authenticator auth.Authenticator, policies auth.Policies) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx, err := authenticate(ctx, authenticator, policies, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func streamAuthInterceptor(authenticator auth.Authenticator, policies auth.Policies) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, err := authenticate(stream.Context(), authenticator, policies, info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, wrapServerStream(stream, ctx))
+	}
+}
+
+// authenticate returns codes.Unauthenticated when credentials are missing or invalid
+// and codes.PermissionDenied when the principal doesn't satisfy the policy,
+// the gateway maps them to 401 and 403.
+func authenticate(ctx context.Context, authenticator auth.Authenticator, policies auth.Policies, fullMethod string) (context.Context, error) {
+	policy := policies.Lookup(fullMethod)
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		md = metadata.New(nil)
+	}
+
+	principal, err := authenticator.Authenticate(ctx, md)
+	if err != nil {
+		// public methods are called anonymously
+		if policy.Public {
+			return ctx, nil
+		}
+
+		return ctx, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	err = policy.Authorize(principal)
+	if err != nil {
+		if errors.Is(err, auth.ErrNoCredentials) {
+			return ctx, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		return ctx, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	return auth.NewContext(ctx, principal), nil
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// APIKeyHeader is the metadata key (and HTTP header on the gateway) with the api key.
+const APIKeyHeader = "x-api-key"
+
+type apiKeyAuthenticator struct {
+	keys map[string]Principal
+}
+
+// NewAPIKeyAuthenticator authenticates callers by static api keys, usually loaded from service config.
+// Keys map an api key to its principal.
+func NewAPIKeyAuthenticator(keys map[string]Principal) Authenticator {
+	return &apiKeyAuthenticator{
+		keys: keys,
+	}
+}
+
+func (a *apiKeyAuthenticator) Authenticate(_ context.Context, md metadata.MD) (*Principal, error) {
+	values := md.Get(APIKeyHeader)
+	if len(values) == 0 || values[0] == "" {
+		return nil, ErrNoCredentials
+	}
+
+	// compare with every key in constant time, so the key can't be guessed by response time
+	var found *Principal
+	for key, principal := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(values[0])) == 1 {
+			principal := principal
+			found = &principal
+		}
+	}
+
+	if found == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return found, nil
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAPIKeyAuthenticator_Authenticate(t *testing.T) {
+	authenticator := NewAPIKeyAuthenticator(map[string]Principal{
+		"key-1": {Subject: "service-1", Roles: []string{"admin"}},
+		"key-2": {Subject: "service-2"},
+	})
+
+	tests := []struct {
+		name        string
+		md          metadata.MD
+		wantSubject string
+		wantErr     error
+	}{
+		{
+			name:        "matching key",
+			md:          metadata.Pairs(APIKeyHeader, "key-1"),
+			wantSubject: "service-1",
+		},
+		{
+			name:        "another matching key",
+			md:          metadata.Pairs(APIKeyHeader, "key-2"),
+			wantSubject: "service-2",
+		},
+		{
+			name:    "unknown key",
+			md:      metadata.Pairs(APIKeyHeader, "key-3"),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "prefix of a key",
+			md:      metadata.Pairs(APIKeyHeader, "key"),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "empty key",
+			md:      metadata.Pairs(APIKeyHeader, ""),
+			wantErr: ErrNoCredentials,
+		},
+		{
+			name:    "no key",
+			md:      metadata.Pairs("authorization", "Bearer token"),
+			wantErr: ErrNoCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(context.Background(), tt.md)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSubject, principal.Subject)
+		})
+	}
+}
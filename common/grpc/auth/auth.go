@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"google.golang.org/grpc/metadata"
+)
+
+var (
+	ErrNoCredentials      = errors.New("credentials are not provided")
+	ErrInvalidCredentials = errors.New("credentials are invalid")
+)
+
+type (
+	// Authenticator extracts credentials from incoming metadata and verifies them.
+	// ErrNoCredentials must be returned when metadata has no credentials supported by the authenticator.
+	Authenticator interface {
+		Authenticate(ctx context.Context, md metadata.MD) (*Principal, error)
+	}
+
+	AuthenticatorFunc func(ctx context.Context, md metadata.MD) (*Principal, error)
+
+	// Principal is an authenticated caller: user, service or api key owner.
+	Principal struct {
+		Subject string
+		Scopes  []string
+		Roles   []string
+		Claims  map[string]interface{}
+	}
+
+	principalKey struct{}
+)
+
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, md metadata.MD) (*Principal, error) {
+	return f(ctx, md)
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// Chain tries authenticators in order until one of them finds credentials in metadata.
+func Chain(authenticators ...Authenticator) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, md metadata.MD) (*Principal, error) {
+		for _, authenticator := range authenticators {
+			principal, err := authenticator.Authenticate(ctx, md)
+			if errors.Is(err, ErrNoCredentials) {
+				continue
+			}
+
+			return principal, err
+		}
+
+		return nil, ErrNoCredentials
+	})
+}
+
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the principal injected by the auth interceptor.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fidesy/sdk/common/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultJWKSRefreshInterval = 10 * time.Minute
+	// unknown kid forces refresh, but not more often than this
+	jwksMinRefreshInterval = time.Minute
+)
+
+type (
+	jwks struct {
+		url             string
+		refreshInterval time.Duration
+		client          *http.Client
+
+		mu          sync.RWMutex
+		keys        map[string]interface{}
+		fetchedAt   time.Time
+		attemptedAt time.Time
+	}
+
+	jsonWebKey struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+)
+
+func newJWKS(url string, refreshInterval time.Duration) *jwks {
+	if refreshInterval == 0 {
+		refreshInterval = defaultJWKSRefreshInterval
+	}
+
+	return &jwks{
+		url:             url,
+		refreshInterval: refreshInterval,
+		client:          &http.Client{Timeout: 5 * time.Second},
+		keys:            make(map[string]interface{}),
+	}
+}
+
+func (j *jwks) key(ctx context.Context, kid string) (interface{}, error) {
+	j.mu.RLock()
+	key, ok := j.keys[kid]
+	stale := time.Since(j.fetchedAt) >= j.refreshInterval
+	canRefresh := time.Since(j.attemptedAt) >= jwksMinRefreshInterval
+	j.mu.RUnlock()
+
+	if ok && !stale {
+		return key, nil
+	}
+
+	if canRefresh {
+		if err := j.refresh(ctx); err != nil {
+			// keep using cached keys while the endpoint is unavailable
+			logger.Errorf("jwks.refresh: %v", err)
+		}
+	}
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	key, ok = j.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown jwks key %q", kid)
+	}
+
+	return key, nil
+}
+
+func (j *jwks) refresh(ctx context.Context) error {
+	j.mu.Lock()
+	j.attemptedAt = time.Now()
+	j.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return fmt.Errorf("http.NewRequestWithContext: %w", err)
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("client.Do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("json.Decode: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(body.Keys))
+	for _, jwk := range body.Keys {
+		key, err := jwk.publicKey()
+		if err != nil {
+			logger.Errorf("jwk.publicKey: %v", err, zap.String("kid", jwk.Kid))
+			continue
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.keys = keys
+	j.fetchedAt = time.Now()
+
+	return nil
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("decode n: %w", err)
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("decode e: %w", err)
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("decode x: %w", err)
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("decode y: %w", err)
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(bytes), nil
+}
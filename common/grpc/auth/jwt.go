@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/metadata"
+)
+
+type (
+	JWTConfig struct {
+		// JWKS endpoint with public keys for RS*, PS* and ES* tokens.
+		JWKSURL string
+		// Default 10m
+		JWKSRefreshInterval time.Duration
+		// Static keys for HS* tokens by kid, the key with empty kid is used for tokens without kid.
+		HMACKeys map[string][]byte
+
+		// Checked when not empty
+		Issuer   string
+		Audience string
+
+		// Default "scope", space separated string or array
+		ScopesClaim string
+		// Default "roles", space separated string or array
+		RolesClaim string
+	}
+
+	jwtAuthenticator struct {
+		config JWTConfig
+		jwks   *jwks
+		parser *jwt.Parser
+	}
+)
+
+func NewJWTAuthenticator(config JWTConfig) (Authenticator, error) {
+	if config.JWKSURL == "" && len(config.HMACKeys) == 0 {
+		return nil, errors.New("JWKSURL or HMACKeys is required")
+	}
+
+	if config.ScopesClaim == "" {
+		config.ScopesClaim = "scope"
+	}
+
+	if config.RolesClaim == "" {
+		config.RolesClaim = "roles"
+	}
+
+	var validMethods []string
+	if len(config.HMACKeys) > 0 {
+		validMethods = append(validMethods, "HS256", "HS384", "HS512")
+	}
+
+	parserOptions := []jwt.ParserOption{
+		jwt.WithExpirationRequired(),
+	}
+	if config.Issuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(config.Audience))
+	}
+
+	a := &jwtAuthenticator{
+		config: config,
+	}
+
+	if config.JWKSURL != "" {
+		a.jwks = newJWKS(config.JWKSURL, config.JWKSRefreshInterval)
+		validMethods = append(validMethods, "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512")
+	}
+
+	a.parser = jwt.NewParser(append(parserOptions, jwt.WithValidMethods(validMethods))...)
+
+	return a, nil
+}
+
+func (a *jwtAuthenticator) Authenticate(ctx context.Context, md metadata.MD) (*Principal, error) {
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, ErrNoCredentials
+	}
+
+	tokenString, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return a.key(ctx, token)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	subject, _ := claims.GetSubject()
+
+	return &Principal{
+		Subject: subject,
+		Scopes:  claimValues(claims[a.config.ScopesClaim]),
+		Roles:   claimValues(claims[a.config.RolesClaim]),
+		Claims:  claims,
+	}, nil
+}
+
+func (a *jwtAuthenticator) key(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		key, ok := a.config.HMACKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown hmac key %q", kid)
+		}
+
+		return key, nil
+	}
+
+	if a.jwks == nil {
+		return nil, errors.New("jwks is not configured")
+	}
+
+	return a.jwks.key(ctx, kid)
+}
+
+// claimValues supports both "a b c" and ["a", "b", "c"] claims.
+func claimValues(claim interface{}) []string {
+	switch value := claim.(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+
+		return values
+	default:
+		return nil
+	}
+}
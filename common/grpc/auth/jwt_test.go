@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+var hmacKey = []byte("secret")
+
+func signHMAC(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(hmacKey)
+	require.NoError(t, err)
+
+	return signed
+}
+
+func bearer(token string) metadata.MD {
+	return metadata.Pairs("authorization", "Bearer "+token)
+}
+
+func TestJWTAuthenticator_Authenticate(t *testing.T) {
+	authenticator, err := NewJWTAuthenticator(JWTConfig{
+		HMACKeys: map[string][]byte{"": hmacKey, "v1": hmacKey},
+		Issuer:   "auth-service",
+	})
+	require.NoError(t, err)
+
+	valid := jwt.MapClaims{
+		"sub":   "user-1",
+		"iss":   "auth-service",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "users:read users:write",
+		"roles": []interface{}{"admin"},
+	}
+
+	expired := jwt.MapClaims{
+		"sub": "user-1",
+		"iss": "auth-service",
+		"exp": time.Now().Add(-time.Minute).Unix(),
+	}
+
+	tests := []struct {
+		name        string
+		md          metadata.MD
+		wantErr     error
+		wantSubject string
+		wantScopes  []string
+		wantRoles   []string
+	}{
+		{
+			name:        "valid token",
+			md:          bearer(signHMAC(t, "", valid)),
+			wantSubject: "user-1",
+			wantScopes:  []string{"users:read", "users:write"},
+			wantRoles:   []string{"admin"},
+		},
+		{
+			name:        "valid token with kid",
+			md:          bearer(signHMAC(t, "v1", valid)),
+			wantSubject: "user-1",
+			wantScopes:  []string{"users:read", "users:write"},
+			wantRoles:   []string{"admin"},
+		},
+		{
+			name:    "no authorization",
+			md:      metadata.MD{},
+			wantErr: ErrNoCredentials,
+		},
+		{
+			name:    "bad prefix",
+			md:      metadata.Pairs("authorization", "Basic dXNlcjpwYXNz"),
+			wantErr: ErrNoCredentials,
+		},
+		{
+			name:    "expired token",
+			md:      bearer(signHMAC(t, "", expired)),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "token without expiration",
+			md: bearer(signHMAC(t, "", jwt.MapClaims{
+				"sub": "user-1",
+				"iss": "auth-service",
+			})),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "wrong issuer",
+			md: bearer(signHMAC(t, "", jwt.MapClaims{
+				"sub": "user-1",
+				"iss": "another-service",
+				"exp": time.Now().Add(time.Hour).Unix(),
+			})),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "unknown kid",
+			md:      bearer(signHMAC(t, "v2", valid)),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "malformed token",
+			md:      bearer("token"),
+			wantErr: ErrInvalidCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(context.Background(), tt.md)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSubject, principal.Subject)
+			require.Equal(t, tt.wantScopes, principal.Scopes)
+			require.Equal(t, tt.wantRoles, principal.Roles)
+		})
+	}
+}
+
+// jwksServer serves public keys of the given private keys by kid.
+type jwksServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	keys     map[string]*rsa.PrivateKey
+	requests int
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	t.Helper()
+
+	s := &jwksServer{keys: make(map[string]*rsa.PrivateKey)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.requests++
+
+		var body struct {
+			Keys []jsonWebKey `json:"keys"`
+		}
+		for kid, key := range s.keys {
+			body.Keys = append(body.Keys, jsonWebKey{
+				Kty: "RSA",
+				Kid: kid,
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *jwksServer) addKey(t *testing.T, kid string) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[kid] = key
+
+	return key
+}
+
+func (s *jwksServer) requestsCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+func signRSA(t *testing.T, kid string, key *rsa.PrivateKey) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"sub": "user-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	return signed
+}
+
+func TestJWTAuthenticator_JWKSRefresh(t *testing.T) {
+	server := newJWKSServer(t)
+	key1 := server.addKey(t, "key-1")
+
+	authenticator, err := NewJWTAuthenticator(JWTConfig{JWKSURL: server.URL})
+	require.NoError(t, err)
+
+	jwks := authenticator.(*jwtAuthenticator).jwks
+	ctx := context.Background()
+
+	// keys are fetched on the first call and cached
+	for i := 0; i < 3; i++ {
+		principal, err := authenticator.Authenticate(ctx, bearer(signRSA(t, "key-1", key1)))
+		require.NoError(t, err)
+		require.Equal(t, "user-1", principal.Subject)
+	}
+	require.Equal(t, 1, server.requestsCount())
+
+	// the key is rotated, unknown kid doesn't refresh keys more often than jwksMinRefreshInterval
+	key2 := server.addKey(t, "key-2")
+
+	_, err = authenticator.Authenticate(ctx, bearer(signRSA(t, "key-2", key2)))
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	require.Equal(t, 1, server.requestsCount())
+
+	// unknown kid refreshes keys after jwksMinRefreshInterval
+	jwks.mu.Lock()
+	jwks.attemptedAt = time.Now().Add(-jwksMinRefreshInterval)
+	jwks.mu.Unlock()
+
+	principal, err := authenticator.Authenticate(ctx, bearer(signRSA(t, "key-2", key2)))
+	require.NoError(t, err)
+	require.Equal(t, "user-1", principal.Subject)
+	require.Equal(t, 2, server.requestsCount())
+
+	// the token signed by another key with a known kid is rejected
+	_, err = authenticator.Authenticate(ctx, bearer(signRSA(t, "key-1", key2)))
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	// cached keys are used while the endpoint is unavailable
+	server.Close()
+
+	jwks.mu.Lock()
+	jwks.attemptedAt = time.Time{}
+	jwks.fetchedAt = time.Time{}
+	jwks.mu.Unlock()
+
+	_, err = authenticator.Authenticate(ctx, bearer(signRSA(t, "key-1", key1)))
+	require.NoError(t, err)
+}
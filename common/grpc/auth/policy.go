@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrPermissionDenied = errors.New("permission denied")
+
+type (
+	// Policy describes who may call a method. Zero value requires any authenticated principal.
+	Policy struct {
+		Public bool
+		// All scopes are required
+		Scopes []string
+		// Any of roles is required
+		Roles []string
+	}
+
+	// Policies maps methods to policies. Keys are full method names ("/users_service.UserService/CreateUser"),
+	// service wildcards ("/users_service.UserService/*") or "*" for every method.
+	Policies map[string]Policy
+)
+
+var (
+	Public        = Policy{Public: true}
+	Authenticated = Policy{}
+)
+
+func RequireScopes(scopes ...string) Policy {
+	return Policy{Scopes: scopes}
+}
+
+func RequireRoles(roles ...string) Policy {
+	return Policy{Roles: roles}
+}
+
+// Lookup returns the most specific policy for the method, Authenticated if nothing matches.
+func (p Policies) Lookup(fullMethod string) Policy {
+	if policy, ok := p[fullMethod]; ok {
+		return policy
+	}
+
+	if i := strings.LastIndex(fullMethod, "/"); i > 0 {
+		if policy, ok := p[fullMethod[:i]+"/*"]; ok {
+			return policy
+		}
+	}
+
+	if policy, ok := p["*"]; ok {
+		return policy
+	}
+
+	return Authenticated
+}
+
+// Authorize checks that the principal satisfies the policy.
+func (p Policy) Authorize(principal *Principal) error {
+	if p.Public {
+		return nil
+	}
+
+	if principal == nil {
+		return ErrNoCredentials
+	}
+
+	for _, scope := range p.Scopes {
+		if !principal.HasScope(scope) {
+			return fmt.Errorf("%w: scope %s is required", ErrPermissionDenied, scope)
+		}
+	}
+
+	if len(p.Roles) == 0 {
+		return nil
+	}
+
+	for _, role := range p.Roles {
+		if principal.HasRole(role) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: one of roles %s is required", ErrPermissionDenied, strings.Join(p.Roles, ", "))
+}
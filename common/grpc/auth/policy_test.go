@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicies_Lookup(t *testing.T) {
+	policies := Policies{
+		"/users.UserService/GetUser":    Public,
+		"/users.UserService/*":          RequireRoles("admin"),
+		"/orders.OrderService/GetOrder": RequireScopes("orders:read"),
+	}
+
+	tests := []struct {
+		name       string
+		policies   Policies
+		fullMethod string
+		want       Policy
+	}{
+		{
+			name:       "exact method",
+			policies:   policies,
+			fullMethod: "/users.UserService/GetUser",
+			want:       Public,
+		},
+		{
+			name:       "service wildcard",
+			policies:   policies,
+			fullMethod: "/users.UserService/DeleteUser",
+			want:       RequireRoles("admin"),
+		},
+		{
+			name:       "no match",
+			policies:   policies,
+			fullMethod: "/orders.OrderService/CreateOrder",
+			want:       Authenticated,
+		},
+		{
+			name:       "global wildcard",
+			policies:   Policies{"*": Public, "/users.UserService/*": Authenticated},
+			fullMethod: "/orders.OrderService/CreateOrder",
+			want:       Public,
+		},
+		{
+			name:       "service wildcard before global wildcard",
+			policies:   Policies{"*": Public, "/users.UserService/*": Authenticated},
+			fullMethod: "/users.UserService/GetUser",
+			want:       Authenticated,
+		},
+		{
+			name:       "nil policies",
+			policies:   nil,
+			fullMethod: "/users.UserService/GetUser",
+			want:       Authenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.policies.Lookup(tt.fullMethod))
+		})
+	}
+}
+
+func TestPolicy_Authorize(t *testing.T) {
+	principal := &Principal{
+		Subject: "user",
+		Scopes:  []string{"users:read", "users:write"},
+		Roles:   []string{"support"},
+	}
+
+	tests := []struct {
+		name      string
+		policy    Policy
+		principal *Principal
+		wantErr   error
+	}{
+		{
+			name:      "public without principal",
+			policy:    Public,
+			principal: nil,
+		},
+		{
+			name:      "authenticated without principal",
+			policy:    Authenticated,
+			principal: nil,
+			wantErr:   ErrNoCredentials,
+		},
+		{
+			name:      "authenticated",
+			policy:    Authenticated,
+			principal: principal,
+		},
+		{
+			name:      "all scopes",
+			policy:    RequireScopes("users:read", "users:write"),
+			principal: principal,
+		},
+		{
+			name:      "missing scope",
+			policy:    RequireScopes("users:read", "users:delete"),
+			principal: principal,
+			wantErr:   ErrPermissionDenied,
+		},
+		{
+			name:      "any of roles",
+			policy:    RequireRoles("admin", "support"),
+			principal: principal,
+		},
+		{
+			name:      "missing role",
+			policy:    RequireRoles("admin"),
+			principal: principal,
+			wantErr:   ErrPermissionDenied,
+		},
+		{
+			name:      "scopes and roles",
+			policy:    Policy{Scopes: []string{"users:write"}, Roles: []string{"admin"}},
+			principal: principal,
+			wantErr:   ErrPermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Authorize(tt.principal)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
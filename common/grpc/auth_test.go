@@ -0,0 +1,103 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fidesy/sdk/common/grpc/auth"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthenticate(t *testing.T) {
+	authenticator := auth.NewAPIKeyAuthenticator(map[string]auth.Principal{
+		"reader": {Subject: "reader", Scopes: []string{"users:read"}},
+	})
+
+	policies := auth.Policies{
+		"/users.UserService/GetUser":    auth.Public,
+		"/users.UserService/ListUsers":  auth.RequireScopes("users:read"),
+		"/users.UserService/DeleteUser": auth.RequireScopes("users:delete"),
+	}
+
+	tests := []struct {
+		name          string
+		fullMethod    string
+		apiKey        string
+		wantCode      codes.Code
+		wantPrincipal string
+	}{
+		{
+			name:       "public without credentials",
+			fullMethod: "/users.UserService/GetUser",
+			wantCode:   codes.OK,
+		},
+		{
+			name:       "public with invalid credentials is called anonymously",
+			fullMethod: "/users.UserService/GetUser",
+			apiKey:     "invalid",
+			wantCode:   codes.OK,
+		},
+		{
+			name:          "public with valid credentials",
+			fullMethod:    "/users.UserService/GetUser",
+			apiKey:        "reader",
+			wantCode:      codes.OK,
+			wantPrincipal: "reader",
+		},
+		{
+			name:       "without credentials",
+			fullMethod: "/users.UserService/ListUsers",
+			wantCode:   codes.Unauthenticated,
+		},
+		{
+			name:       "invalid credentials",
+			fullMethod: "/users.UserService/ListUsers",
+			apiKey:     "invalid",
+			wantCode:   codes.Unauthenticated,
+		},
+		{
+			name:          "authorized",
+			fullMethod:    "/users.UserService/ListUsers",
+			apiKey:        "reader",
+			wantCode:      codes.OK,
+			wantPrincipal: "reader",
+		},
+		{
+			name:       "permission denied",
+			fullMethod: "/users.UserService/DeleteUser",
+			apiKey:     "reader",
+			wantCode:   codes.PermissionDenied,
+		},
+		{
+			name:          "authenticated by default",
+			fullMethod:    "/users.UserService/UpdateUser",
+			apiKey:        "reader",
+			wantCode:      codes.OK,
+			wantPrincipal: "reader",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.apiKey != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(auth.APIKeyHeader, tt.apiKey))
+			}
+
+			ctx, err := authenticate(ctx, authenticator, policies, tt.fullMethod)
+			require.Equal(t, tt.wantCode, status.Code(err))
+
+			principal, ok := auth.FromContext(ctx)
+			if tt.wantPrincipal == "" {
+				require.False(t, ok)
+				return
+			}
+
+			require.True(t, ok)
+			require.Equal(t, tt.wantPrincipal, principal.Subject)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package balancer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+
+	grpcResolver "github.com/fidesy/sdk/common/grpc/resolver"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+const (
+	// Weighted distributes calls between instances in proportion to their weights.
+	Weighted = "weighted"
+	// ZoneAware balances calls by weight between instances of the client zone,
+	// instances of other zones are used only if there are no ready ones in the zone.
+	ZoneAware = "zone_aware"
+)
+
+func init() {
+	balancer.Register(&builder{name: Weighted})
+	balancer.Register(&builder{name: ZoneAware, zoneAware: true})
+}
+
+type (
+	// Config is the load balancing config of the policies in the service config.
+	Config struct {
+		serviceconfig.LoadBalancingConfig `json:"-"`
+
+		// Zone of the client for ZoneAware policy. Default ZONE env
+		Zone string `json:"zone,omitempty"`
+	}
+
+	builder struct {
+		name      string
+		zoneAware bool
+	}
+
+	// wrappedBalancer keeps up-to-date instance attributes for the picker,
+	// base balancer stores attributes of an address only when its SubConn is created.
+	wrappedBalancer struct {
+		balancer.Balancer
+		pickerBuilder *pickerBuilder
+	}
+
+	pickerBuilder struct {
+		zoneAware bool
+
+		mu        sync.Mutex
+		zone      string
+		instances *resolver.AddressMap
+	}
+
+	weightedSubConn struct {
+		subConn balancer.SubConn
+		weight  int64
+		current int64
+	}
+
+	// picker is a smooth weighted round robin.
+	picker struct {
+		mu       sync.Mutex
+		subConns []*weightedSubConn
+		total    int64
+	}
+)
+
+func (b *builder) Name() string {
+	return b.name
+}
+
+func (b *builder) ParseConfig(js json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	config := &Config{}
+	if err := json.Unmarshal(js, config); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	return config, nil
+}
+
+func (b *builder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	pb := &pickerBuilder{
+		zoneAware: b.zoneAware,
+		zone:      os.Getenv("ZONE"),
+		instances: resolver.NewAddressMap(),
+	}
+
+	return &wrappedBalancer{
+		Balancer:      base.NewBalancerBuilder(b.name, pb, base.Config{HealthCheck: true}).Build(cc, opts),
+		pickerBuilder: pb,
+	}
+}
+
+func (b *wrappedBalancer) UpdateClientConnState(state balancer.ClientConnState) error {
+	instances := resolver.NewAddressMap()
+	for _, addr := range state.ResolverState.Addresses {
+		instances.Set(addr, grpcResolver.GetInstance(addr))
+	}
+
+	b.pickerBuilder.mu.Lock()
+	b.pickerBuilder.instances = instances
+	if config, ok := state.BalancerConfig.(*Config); ok && config.Zone != "" {
+		b.pickerBuilder.zone = config.Zone
+	}
+	b.pickerBuilder.mu.Unlock()
+
+	return b.Balancer.UpdateClientConnState(state)
+}
+
+func (pb *pickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	var all, local []*weightedSubConn
+	for subConn, subConnInfo := range info.ReadySCs {
+		instance := grpcResolver.GetInstance(subConnInfo.Address)
+		if value, ok := pb.instances.Get(subConnInfo.Address); ok {
+			instance = value.(grpcResolver.Instance)
+		}
+
+		wsc := &weightedSubConn{
+			subConn: subConn,
+			weight:  int64(instance.Weight),
+		}
+
+		all = append(all, wsc)
+		if pb.zone != "" && instance.Zone == pb.zone {
+			local = append(local, wsc)
+		}
+	}
+
+	subConns := all
+	if pb.zoneAware && len(local) > 0 {
+		subConns = local
+	}
+
+	p := &picker{subConns: subConns}
+	for _, wsc := range subConns {
+		p.total += wsc.weight
+	}
+
+	// start from a random position of the cycle, so clients do not call the same instance first
+	for i := rand.Intn(len(subConns)); i > 0; i-- {
+		p.next()
+	}
+
+	return p
+}
+
+func (p *picker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return balancer.PickResult{SubConn: p.next()}, nil
+}
+
+func (p *picker) next() balancer.SubConn {
+	var best *weightedSubConn
+	for _, wsc := range p.subConns {
+		wsc.current += wsc.weight
+		if best == nil || wsc.current > best.current {
+			best = wsc
+		}
+	}
+
+	best.current -= p.total
+
+	return best.subConn
+}
@@ -0,0 +1,188 @@
+package balancer
+
+import (
+	"testing"
+
+	grpcResolver "github.com/fidesy/sdk/common/grpc/resolver"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+type fakeSubConn struct {
+	balancer.SubConn
+	addr string
+}
+
+type testInstance struct {
+	addr   string
+	weight uint32
+	zone   string
+}
+
+func buildInfo(instances []testInstance) base.PickerBuildInfo {
+	info := base.PickerBuildInfo{ReadySCs: make(map[balancer.SubConn]base.SubConnInfo)}
+	for _, instance := range instances {
+		info.ReadySCs[&fakeSubConn{addr: instance.addr}] = base.SubConnInfo{
+			Address: grpcResolver.SetInstance(resolver.Address{Addr: instance.addr}, grpcResolver.Instance{
+				Weight: instance.weight,
+				Zone:   instance.zone,
+			}),
+		}
+	}
+
+	return info
+}
+
+// pickCounts picks n times and counts picks of every address.
+func pickCounts(t *testing.T, p balancer.Picker, n int) map[string]int {
+	t.Helper()
+
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		result, err := p.Pick(balancer.PickInfo{})
+		require.NoError(t, err)
+
+		counts[result.SubConn.(*fakeSubConn).addr]++
+	}
+
+	return counts
+}
+
+func TestPickerBuilder_Build(t *testing.T) {
+	tests := []struct {
+		name      string
+		zoneAware bool
+		zone      string
+		instances []testInstance
+		want      map[string]int
+	}{
+		{
+			name: "weighted",
+			instances: []testInstance{
+				{addr: "a:1", weight: 1},
+				{addr: "b:1", weight: 2},
+				{addr: "c:1", weight: 3},
+			},
+			want: map[string]int{"a:1": 100, "b:1": 200, "c:1": 300},
+		},
+		{
+			name: "default weight",
+			instances: []testInstance{
+				{addr: "a:1"},
+				{addr: "b:1", weight: 2},
+			},
+			want: map[string]int{"a:1": 200, "b:1": 400},
+		},
+		{
+			name: "weighted ignores zones",
+			zone: "zone-a",
+			instances: []testInstance{
+				{addr: "a:1", weight: 1, zone: "zone-a"},
+				{addr: "b:1", weight: 1, zone: "zone-b"},
+			},
+			want: map[string]int{"a:1": 300, "b:1": 300},
+		},
+		{
+			name:      "zone aware uses instances of the zone",
+			zoneAware: true,
+			zone:      "zone-a",
+			instances: []testInstance{
+				{addr: "a:1", weight: 1, zone: "zone-a"},
+				{addr: "a:2", weight: 2, zone: "zone-a"},
+				{addr: "b:1", weight: 3, zone: "zone-b"},
+			},
+			want: map[string]int{"a:1": 200, "a:2": 400},
+		},
+		{
+			name:      "zone aware falls back to other zones",
+			zoneAware: true,
+			zone:      "zone-c",
+			instances: []testInstance{
+				{addr: "a:1", weight: 1, zone: "zone-a"},
+				{addr: "b:1", weight: 2, zone: "zone-b"},
+			},
+			want: map[string]int{"a:1": 200, "b:1": 400},
+		},
+		{
+			name:      "zone aware without client zone",
+			zoneAware: true,
+			instances: []testInstance{
+				{addr: "a:1", weight: 1, zone: "zone-a"},
+				{addr: "b:1", weight: 1, zone: "zone-b"},
+			},
+			want: map[string]int{"a:1": 300, "b:1": 300},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pb := &pickerBuilder{
+				zoneAware: tt.zoneAware,
+				zone:      tt.zone,
+				instances: resolver.NewAddressMap(),
+			}
+
+			p := pb.Build(buildInfo(tt.instances))
+			require.Equal(t, tt.want, pickCounts(t, p, 600))
+		})
+	}
+}
+
+func TestPickerBuilder_Build_NoReadySubConns(t *testing.T) {
+	pb := &pickerBuilder{instances: resolver.NewAddressMap()}
+
+	_, err := pb.Build(base.PickerBuildInfo{}).Pick(balancer.PickInfo{})
+	require.ErrorIs(t, err, balancer.ErrNoSubConnAvailable)
+}
+
+func TestPickerBuilder_Build_UpdatedInstances(t *testing.T) {
+	// SubConns keep attributes of the address they were created with,
+	// the picker uses the ones from the last resolver update
+	instances := resolver.NewAddressMap()
+	instances.Set(resolver.Address{Addr: "a:1"}, grpcResolver.Instance{Weight: 3})
+	instances.Set(resolver.Address{Addr: "b:1"}, grpcResolver.Instance{Weight: 1})
+
+	pb := &pickerBuilder{instances: instances}
+	p := pb.Build(buildInfo([]testInstance{
+		{addr: "a:1", weight: 1},
+		{addr: "b:1", weight: 1},
+	}))
+
+	require.Equal(t, map[string]int{"a:1": 300, "b:1": 100}, pickCounts(t, p, 400))
+}
+
+func TestPicker_Smooth(t *testing.T) {
+	pb := &pickerBuilder{instances: resolver.NewAddressMap()}
+	p := pb.Build(buildInfo([]testInstance{
+		{addr: "a:1", weight: 5},
+		{addr: "b:1", weight: 1},
+		{addr: "c:1", weight: 1},
+	}))
+
+	// calls of the heaviest instance are interleaved with others, not sent in a row
+	previous, inRow := "", 0
+	for i := 0; i < 70; i++ {
+		result, err := p.Pick(balancer.PickInfo{})
+		require.NoError(t, err)
+
+		addr := result.SubConn.(*fakeSubConn).addr
+		if addr == previous {
+			inRow++
+		} else {
+			previous, inRow = addr, 1
+		}
+
+		require.LessOrEqual(t, inRow, 4)
+	}
+}
+
+func TestBuilder_ParseConfig(t *testing.T) {
+	config, err := (&builder{name: ZoneAware}).ParseConfig([]byte(`{"zone": "zone-a"}`))
+	require.NoError(t, err)
+	require.Equal(t, "zone-a", config.(*Config).Zone)
+
+	_, err = (&builder{name: ZoneAware}).ParseConfig([]byte(`{"zone": 1}`))
+	require.Error(t, err)
+}
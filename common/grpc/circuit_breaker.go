@@ -0,0 +1,414 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fidesy/sdk/common/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type circuitBreakerState int
+
+const (
+	circuitBreakerClosed circuitBreakerState = iota
+	circuitBreakerHalfOpen
+	circuitBreakerOpen
+)
+
+func (s circuitBreakerState) String() string {
+	switch s {
+	case circuitBreakerClosed:
+		return "closed"
+	case circuitBreakerHalfOpen:
+		return "half-open"
+	default:
+		return "open"
+	}
+}
+
+type (
+	CircuitBreakerConfig struct {
+		// Failure rate is calculated over this window. Default 10s
+		Window time.Duration
+		// Min requests in the window to open the breaker. Default 20
+		MinRequests int
+		// Failure rate from 0 to 1 which opens the breaker. Default 0.5
+		FailureRate float64
+		// Calls longer than this are counted as failures, 0 disables it
+		SlowCallThreshold time.Duration
+		// Time in open state before probe requests are allowed. Default 30s
+		OpenTimeout time.Duration
+		// Successful probe requests in half-open state to close the breaker. Default 5
+		HalfOpenRequests int
+		// Default codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.ResourceExhausted
+		FailureCodes []codes.Code
+	}
+
+	circuitBreakers struct {
+		target string
+		config CircuitBreakerConfig
+		// replaced in tests
+		now func() time.Time
+
+		mu       sync.Mutex
+		breakers map[string]*circuitBreaker
+	}
+
+	// circuitBreaker of one method.
+	circuitBreaker struct {
+		target string
+		method string
+		config CircuitBreakerConfig
+		now    func() time.Time
+
+		mu          sync.Mutex
+		state       circuitBreakerState
+		windowStart time.Time
+		requests    int
+		failures    int
+		openedAt    time.Time
+		probes      int
+		successes   int
+	}
+)
+
+// WithCircuitBreaker stops calls of a failing method for a while, returning codes.Unavailable immediately.
+func WithCircuitBreaker(config CircuitBreakerConfig) ClientOption {
+	return func(c *clientConfig) error {
+		if config.Window == 0 {
+			config.Window = 10 * time.Second
+		}
+
+		if config.MinRequests == 0 {
+			config.MinRequests = 20
+		}
+
+		if config.FailureRate == 0 {
+			config.FailureRate = 0.5
+		}
+
+		if config.FailureRate < 0 || config.FailureRate > 1 {
+			return fmt.Errorf("circuit breaker failure rate must be from 0 to 1, got %v", config.FailureRate)
+		}
+
+		if config.OpenTimeout == 0 {
+			config.OpenTimeout = 30 * time.Second
+		}
+
+		if config.HalfOpenRequests == 0 {
+			config.HalfOpenRequests = 5
+		}
+
+		if len(config.FailureCodes) == 0 {
+			config.FailureCodes = []codes.Code{codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.ResourceExhausted}
+		}
+
+		c.circuitBreaker = &config
+		return nil
+	}
+}
+
+// WithBulkhead limits concurrent calls and streams of the client. A call waits for a free slot
+// up to maxWait and then fails with codes.Unavailable. A stream holds the slot until it is finished.
+func WithBulkhead(maxConcurrent int, maxWait time.Duration) ClientOption {
+	return func(c *clientConfig) error {
+		if maxConcurrent <= 0 {
+			return fmt.Errorf("bulkhead max concurrent calls must be positive, got %d", maxConcurrent)
+		}
+
+		c.bulkheadSize = maxConcurrent
+		c.bulkheadWait = maxWait
+		return nil
+	}
+}
+
+func newCircuitBreakers(target string, config CircuitBreakerConfig) *circuitBreakers {
+	return &circuitBreakers{
+		target:   target,
+		config:   config,
+		now:      time.Now,
+		breakers: make(map[string]*circuitBreaker),
+	}
+}
+
+func (b *circuitBreakers) get(method string) *circuitBreaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	breaker, ok := b.breakers[method]
+	if !ok {
+		breaker = &circuitBreaker{
+			target:      b.target,
+			method:      method,
+			config:      b.config,
+			now:         b.now,
+			windowStart: b.now(),
+		}
+		b.breakers[method] = breaker
+		metrics.Load().clientCircuitBreakerChanged(b.target, method, circuitBreakerClosed, false)
+	}
+
+	return breaker
+}
+
+// allow reports whether the call may be done, done must be called with the call result.
+func (cb *circuitBreaker) allow() (done func(err error, duration time.Duration), ok bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == circuitBreakerOpen {
+		if cb.now().Sub(cb.openedAt) < cb.config.OpenTimeout {
+			return nil, false
+		}
+
+		cb.setState(circuitBreakerHalfOpen)
+	}
+
+	if cb.state == circuitBreakerHalfOpen {
+		if cb.probes >= cb.config.HalfOpenRequests {
+			return nil, false
+		}
+
+		cb.probes++
+	}
+
+	state := cb.state
+
+	return func(err error, duration time.Duration) {
+		cb.record(state, cb.isFailure(err, duration))
+	}, true
+}
+
+func (cb *circuitBreaker) record(state circuitBreakerState, failure bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	// result of a call started before the last transition
+	if state != cb.state {
+		return
+	}
+
+	switch cb.state {
+	case circuitBreakerHalfOpen:
+		if failure {
+			cb.setState(circuitBreakerOpen)
+			return
+		}
+
+		cb.successes++
+		if cb.successes >= cb.config.HalfOpenRequests {
+			cb.setState(circuitBreakerClosed)
+		}
+	case circuitBreakerClosed:
+		if cb.now().Sub(cb.windowStart) > cb.config.Window {
+			cb.windowStart = cb.now()
+			cb.requests = 0
+			cb.failures = 0
+		}
+
+		cb.requests++
+		if failure {
+			cb.failures++
+		}
+
+		if cb.requests >= cb.config.MinRequests &&
+			float64(cb.failures)/float64(cb.requests) >= cb.config.FailureRate {
+			cb.setState(circuitBreakerOpen)
+		}
+	}
+}
+
+func (cb *circuitBreaker) isFailure(err error, duration time.Duration) bool {
+	if cb.config.SlowCallThreshold > 0 && duration > cb.config.SlowCallThreshold {
+		return true
+	}
+
+	return err != nil && slices.Contains(cb.config.FailureCodes, status.Code(err))
+}
+
+func (cb *circuitBreaker) setState(state circuitBreakerState) {
+	logger.Info(
+		fmt.Sprintf("circuit breaker is %s", state),
+		zap.String("target", cb.target),
+		zap.String("handler", cb.method),
+		zap.String("previous_state", cb.state.String()),
+	)
+
+	cb.state = state
+	cb.windowStart = cb.now()
+	cb.requests = 0
+	cb.failures = 0
+	cb.probes = 0
+	cb.successes = 0
+
+	if state == circuitBreakerOpen {
+		cb.openedAt = cb.now()
+	}
+
+	metrics.Load().clientCircuitBreakerChanged(cb.target, cb.method, state, true)
+}
+
+func clientCircuitBreakerInterceptor(breakers *circuitBreakers) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		done, ok := breakers.get(method).allow()
+		if !ok {
+			metrics.Load().clientRejectedRPC(breakers.target, method, "circuit_breaker")
+			return status.Errorf(codes.Unavailable, "circuit breaker for %s is open", method)
+		}
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		done(err, time.Since(start))
+
+		return err
+	}
+}
+
+func streamClientCircuitBreakerInterceptor(breakers *circuitBreakers) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		done, ok := breakers.get(method).allow()
+		if !ok {
+			metrics.Load().clientRejectedRPC(breakers.target, method, "circuit_breaker")
+			return nil, status.Errorf(codes.Unavailable, "circuit breaker for %s is open", method)
+		}
+
+		// only stream creation is taken into account
+		start := time.Now()
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		done(err, time.Since(start))
+
+		return stream, err
+	}
+}
+
+func clientBulkheadInterceptor(target string, slots chan struct{}, maxWait time.Duration) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if !acquireSlot(ctx, slots, maxWait) {
+			metrics.Load().clientRejectedRPC(target, method, "bulkhead")
+			return status.Errorf(codes.Unavailable, "too many concurrent calls to %s", target)
+		}
+		defer func() { <-slots }()
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func streamClientBulkheadInterceptor(target string, slots chan struct{}, maxWait time.Duration) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		if !acquireSlot(ctx, slots, maxWait) {
+			metrics.Load().clientRejectedRPC(target, method, "bulkhead")
+			return nil, status.Errorf(codes.Unavailable, "too many concurrent calls to %s", target)
+		}
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			<-slots
+			return nil, err
+		}
+
+		s := &bulkheadClientStream{
+			ClientStream: stream,
+			desc:         desc,
+			slots:        slots,
+			done:         make(chan struct{}),
+		}
+
+		// the stream is finished by cancellation without RecvMsg error if the caller stops reading
+		go func() {
+			select {
+			case <-ctx.Done():
+				s.release()
+			case <-s.done:
+			}
+		}()
+
+		return s, nil
+	}
+}
+
+// bulkheadClientStream holds the bulkhead slot until the stream is finished.
+type bulkheadClientStream struct {
+	grpc.ClientStream
+	desc  *grpc.StreamDesc
+	slots chan struct{}
+
+	once sync.Once
+	done chan struct{}
+}
+
+func (s *bulkheadClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+
+	// the only response of client streaming RPC finishes it
+	if err != nil || !s.desc.ServerStreams {
+		s.release()
+	}
+
+	return err
+}
+
+func (s *bulkheadClientStream) release() {
+	s.once.Do(func() {
+		close(s.done)
+		<-s.slots
+	})
+}
+
+func acquireSlot(ctx context.Context, slots chan struct{}, maxWait time.Duration) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if maxWait <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
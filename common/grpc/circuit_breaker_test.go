@@ -0,0 +1,430 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testMethod = "/users.UserService/GetUser"
+
+var (
+	errUnavailable = status.Error(codes.Unavailable, "unavailable")
+	errNotFound    = status.Error(codes.NotFound, "not found")
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestCircuitBreakers(t *testing.T, config CircuitBreakerConfig) (*circuitBreakers, *fakeClock) {
+	t.Helper()
+
+	c := &clientConfig{}
+	require.NoError(t, WithCircuitBreaker(config)(c))
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	breakers := newCircuitBreakers("users-service", *c.circuitBreaker)
+	breakers.now = clock.Now
+
+	return breakers, clock
+}
+
+// call makes a call through the breaker, returns false if it is rejected.
+func call(cb *circuitBreaker, err error) bool {
+	done, ok := cb.allow()
+	if !ok {
+		return false
+	}
+
+	done(err, time.Millisecond)
+
+	return true
+}
+
+func TestCircuitBreaker_Trip(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  []error
+		wantOpen bool
+	}{
+		{
+			name:     "failure rate reached",
+			results:  []error{nil, nil, errUnavailable, errUnavailable},
+			wantOpen: true,
+		},
+		{
+			name:     "failure rate not reached",
+			results:  []error{nil, nil, nil, errUnavailable},
+			wantOpen: false,
+		},
+		{
+			name:     "not enough requests",
+			results:  []error{errUnavailable, errUnavailable, errUnavailable},
+			wantOpen: false,
+		},
+		{
+			name:     "codes which are not failures",
+			results:  []error{errNotFound, errNotFound, errNotFound, errNotFound},
+			wantOpen: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakers, _ := newTestCircuitBreakers(t, CircuitBreakerConfig{
+				MinRequests: 4,
+				FailureRate: 0.5,
+			})
+			cb := breakers.get(testMethod)
+
+			for _, err := range tt.results {
+				require.True(t, call(cb, err))
+			}
+
+			require.Equal(t, tt.wantOpen, !call(cb, nil))
+		})
+	}
+}
+
+func TestCircuitBreaker_Window(t *testing.T) {
+	breakers, clock := newTestCircuitBreakers(t, CircuitBreakerConfig{
+		Window:      10 * time.Second,
+		MinRequests: 4,
+		FailureRate: 0.5,
+	})
+	cb := breakers.get(testMethod)
+
+	require.True(t, call(cb, errUnavailable))
+	require.True(t, call(cb, errUnavailable))
+	require.True(t, call(cb, errUnavailable))
+
+	// failures of the previous window are forgotten
+	clock.Advance(11 * time.Second)
+
+	require.True(t, call(cb, errUnavailable))
+	require.True(t, call(cb, nil))
+	require.True(t, call(cb, nil))
+	require.True(t, call(cb, nil))
+	require.Equal(t, circuitBreakerClosed, cb.state)
+}
+
+func TestCircuitBreaker_SlowCalls(t *testing.T) {
+	breakers, _ := newTestCircuitBreakers(t, CircuitBreakerConfig{
+		MinRequests:       2,
+		FailureRate:       1,
+		SlowCallThreshold: time.Second,
+	})
+	cb := breakers.get(testMethod)
+
+	for i := 0; i < 2; i++ {
+		done, ok := cb.allow()
+		require.True(t, ok)
+		done(nil, 2*time.Second)
+	}
+
+	require.Equal(t, circuitBreakerOpen, cb.state)
+}
+
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	config := CircuitBreakerConfig{
+		MinRequests:      2,
+		FailureRate:      1,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenRequests: 2,
+	}
+
+	open := func(t *testing.T) (*circuitBreaker, *fakeClock) {
+		breakers, clock := newTestCircuitBreakers(t, config)
+		cb := breakers.get(testMethod)
+
+		require.True(t, call(cb, errUnavailable))
+		require.True(t, call(cb, errUnavailable))
+		require.Equal(t, circuitBreakerOpen, cb.state)
+
+		return cb, clock
+	}
+
+	t.Run("rejected until open timeout", func(t *testing.T) {
+		cb, clock := open(t)
+
+		clock.Advance(29 * time.Second)
+		require.False(t, call(cb, nil))
+		require.Equal(t, circuitBreakerOpen, cb.state)
+	})
+
+	t.Run("limited probes", func(t *testing.T) {
+		cb, clock := open(t)
+		clock.Advance(30 * time.Second)
+
+		done1, ok := cb.allow()
+		require.True(t, ok)
+		require.Equal(t, circuitBreakerHalfOpen, cb.state)
+
+		done2, ok := cb.allow()
+		require.True(t, ok)
+
+		// probes are in flight, other calls are rejected
+		_, ok = cb.allow()
+		require.False(t, ok)
+
+		done1(nil, time.Millisecond)
+		done2(nil, time.Millisecond)
+		require.Equal(t, circuitBreakerClosed, cb.state)
+	})
+
+	t.Run("recovery", func(t *testing.T) {
+		cb, clock := open(t)
+		clock.Advance(30 * time.Second)
+
+		require.True(t, call(cb, nil))
+		require.Equal(t, circuitBreakerHalfOpen, cb.state)
+		require.True(t, call(cb, nil))
+		require.Equal(t, circuitBreakerClosed, cb.state)
+
+		// the closed breaker counts failures from scratch
+		require.True(t, call(cb, errUnavailable))
+		require.True(t, call(cb, nil))
+		require.Equal(t, circuitBreakerClosed, cb.state)
+	})
+
+	t.Run("failed probe", func(t *testing.T) {
+		cb, clock := open(t)
+		clock.Advance(30 * time.Second)
+
+		require.True(t, call(cb, nil))
+		require.True(t, call(cb, errUnavailable))
+		require.Equal(t, circuitBreakerOpen, cb.state)
+
+		// open timeout starts again
+		clock.Advance(29 * time.Second)
+		require.False(t, call(cb, nil))
+
+		clock.Advance(time.Second)
+		require.True(t, call(cb, nil))
+	})
+
+	t.Run("result of a call started before the transition", func(t *testing.T) {
+		breakers, _ := newTestCircuitBreakers(t, config)
+		cb := breakers.get(testMethod)
+
+		done, ok := cb.allow()
+		require.True(t, ok)
+
+		require.True(t, call(cb, errUnavailable))
+		require.True(t, call(cb, errUnavailable))
+		require.Equal(t, circuitBreakerOpen, cb.state)
+
+		done(nil, time.Millisecond)
+		require.Equal(t, circuitBreakerOpen, cb.state)
+	})
+}
+
+func TestClientCircuitBreakerInterceptor(t *testing.T) {
+	breakers, _ := newTestCircuitBreakers(t, CircuitBreakerConfig{
+		MinRequests: 1,
+		FailureRate: 1,
+	})
+	interceptor := clientCircuitBreakerInterceptor(breakers)
+
+	calls := 0
+	invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		calls++
+		return errUnavailable
+	}
+
+	err := interceptor(context.Background(), testMethod, nil, nil, nil, invoker)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, 1, calls)
+
+	// the breaker is open, the call isn't made
+	err = interceptor(context.Background(), testMethod, nil, nil, nil, invoker)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, 1, calls)
+
+	// breakers are per method
+	err = interceptor(context.Background(), "/users.UserService/ListUsers", nil, nil, nil, invoker)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, 2, calls)
+}
+
+func TestClientBulkheadInterceptor(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxWait  time.Duration
+		release  bool
+		wantCode codes.Code
+	}{
+		{
+			name:     "rejected without waiting",
+			wantCode: codes.Unavailable,
+		},
+		{
+			name:     "rejected after waiting",
+			maxWait:  10 * time.Millisecond,
+			wantCode: codes.Unavailable,
+		},
+		{
+			name:     "slot is released while waiting",
+			maxWait:  time.Minute,
+			release:  true,
+			wantCode: codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interceptor := clientBulkheadInterceptor("users-service", make(chan struct{}, 1), tt.maxWait)
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+			blocking := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+				close(started)
+				<-release
+				return nil
+			}
+			invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+				return nil
+			}
+
+			first := make(chan error, 1)
+			go func() {
+				first <- interceptor(context.Background(), testMethod, nil, nil, nil, blocking)
+			}()
+			<-started
+
+			if tt.release {
+				time.AfterFunc(10*time.Millisecond, func() { close(release) })
+			}
+
+			err := interceptor(context.Background(), testMethod, nil, nil, nil, invoker)
+			require.Equal(t, tt.wantCode, status.Code(err))
+
+			if !tt.release {
+				close(release)
+			}
+			require.NoError(t, <-first)
+		})
+	}
+}
+
+type fakeClientStream struct {
+	grpc.ClientStream
+	recv chan error
+}
+
+func (s *fakeClientStream) RecvMsg(interface{}) error {
+	return <-s.recv
+}
+
+func TestStreamClientBulkheadInterceptor(t *testing.T) {
+	serverStream := &grpc.StreamDesc{ServerStreams: true}
+	clientStream := &grpc.StreamDesc{ClientStreams: true}
+
+	tests := []struct {
+		name string
+		desc *grpc.StreamDesc
+		// finish finishes the stream, it must release the slot
+		finish func(stream grpc.ClientStream, recv chan error, cancel context.CancelFunc)
+	}{
+		{
+			name: "stream is finished",
+			desc: serverStream,
+			finish: func(stream grpc.ClientStream, recv chan error, _ context.CancelFunc) {
+				recv <- nil
+				require.NoError(t, stream.RecvMsg(nil))
+
+				recv <- io.EOF
+				require.ErrorIs(t, stream.RecvMsg(nil), io.EOF)
+			},
+		},
+		{
+			name: "stream failed",
+			desc: serverStream,
+			finish: func(stream grpc.ClientStream, recv chan error, _ context.CancelFunc) {
+				recv <- errUnavailable
+				require.Error(t, stream.RecvMsg(nil))
+			},
+		},
+		{
+			name: "context is done",
+			desc: serverStream,
+			finish: func(_ grpc.ClientStream, _ chan error, cancel context.CancelFunc) {
+				cancel()
+			},
+		},
+		{
+			name: "response of client streaming",
+			desc: clientStream,
+			finish: func(stream grpc.ClientStream, recv chan error, _ context.CancelFunc) {
+				recv <- nil
+				require.NoError(t, stream.RecvMsg(nil))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slots := make(chan struct{}, 1)
+			interceptor := streamClientBulkheadInterceptor("users-service", slots, 0)
+			unaryInterceptor := clientBulkheadInterceptor("users-service", slots, 0)
+
+			recv := make(chan error, 1)
+			streamer := func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+				return &fakeClientStream{recv: recv}, nil
+			}
+			invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+				return nil
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			stream, err := interceptor(ctx, tt.desc, nil, testMethod, streamer)
+			require.NoError(t, err)
+
+			// the open stream holds the slot shared with unary calls
+			_, err = interceptor(context.Background(), tt.desc, nil, testMethod, streamer)
+			require.Equal(t, codes.Unavailable, status.Code(err))
+
+			err = unaryInterceptor(context.Background(), testMethod, nil, nil, nil, invoker)
+			require.Equal(t, codes.Unavailable, status.Code(err))
+
+			tt.finish(stream, recv, cancel)
+
+			require.Eventually(t, func() bool {
+				return unaryInterceptor(context.Background(), testMethod, nil, nil, nil, invoker) == nil
+			}, time.Second, time.Millisecond)
+		})
+	}
+}
+
+func TestStreamClientBulkheadInterceptor_StreamerError(t *testing.T) {
+	slots := make(chan struct{}, 1)
+	interceptor := streamClientBulkheadInterceptor("users-service", slots, 0)
+
+	streamer := func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+		return nil, errUnavailable
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := interceptor(context.Background(), &grpc.StreamDesc{ServerStreams: true}, nil, testMethod, streamer)
+		require.ErrorIs(t, err, errUnavailable)
+	}
+
+	require.Empty(t, slots)
+}
@@ -2,9 +2,14 @@ package grpc
 
 import (
 	"context"
+	"fmt"
+	"time"
+
+	"github.com/fidesy/sdk/common/grpc/balancer"
 	domain_name_service "github.com/fidesy/sdk/common/grpc/pkg/domain-name-service"
 	"github.com/samber/lo"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
@@ -12,14 +17,71 @@ var (
 	domainNameServiceClient domain_name_service.DomainNameServiceClient
 )
 
-type ConnWrapper[Client any] func(_ grpc.ClientConnInterface) Client
+// Load balancing policies of WithLoadBalancing.
+const (
+	RoundRobin = "round_robin"
+	PickFirst  = "pick_first"
+	// Weighted uses instance weights registered in domain-name-service.
+	Weighted = balancer.Weighted
+	// ZoneAware prefers instances of the client zone (ZONE env) and balances them by weight.
+	ZoneAware = balancer.ZoneAware
+)
+
+type (
+	ConnWrapper[Client any] func(_ grpc.ClientConnInterface) Client
+
+	ClientOption func(c *clientConfig) error
+	clientConfig struct {
+		transportCredentials credentials.TransportCredentials
+		dialOptions          []grpc.DialOption
+		loadBalancing        string
+
+		metrics        bool
+		tracing        bool
+		defaultTimeout time.Duration
+		retryPolicy    *RetryPolicy
+
+		circuitBreaker *CircuitBreakerConfig
+		bulkheadSize   int
+		bulkheadWait   time.Duration
+	}
+)
+
+// WithClientTLS enables TLS for the connection, with CertFile and KeyFile it is mutual TLS.
+func WithClientTLS(config TLSConfig) ClientOption {
+	return func(c *clientConfig) error {
+		tlsConfig, err := config.clientConfig()
+		if err != nil {
+			return fmt.Errorf("TLSConfig.clientConfig: %w", err)
+		}
+
+		c.transportCredentials = credentials.NewTLS(tlsConfig)
+		return nil
+	}
+}
+
+// WithDialOptions passes raw options to grpc.DialContext.
+func WithDialOptions(options ...grpc.DialOption) ClientOption {
+	return func(c *clientConfig) error {
+		c.dialOptions = append(c.dialOptions, options...)
+		return nil
+	}
+}
+
+// WithLoadBalancing sets load balancing policy of the client. Default RoundRobin
+func WithLoadBalancing(policy string) ClientOption {
+	return func(c *clientConfig) error {
+		if policy == "" {
+			return fmt.Errorf("load balancing policy is empty")
+		}
+
+		c.loadBalancing = policy
+		return nil
+	}
+}
 
-func NewDomainNameService(ctx context.Context, domainNameServiceHost string) error {
-	conn, err := grpc.DialContext(
-		ctx,
-		domainNameServiceHost,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+func NewDomainNameService(ctx context.Context, domainNameServiceHost string, options ...ClientOption) error {
+	conn, err := dial(ctx, domainNameServiceHost, options...)
 	if err != nil {
 		return err
 	}
@@ -29,15 +91,41 @@ func NewDomainNameService(ctx context.Context, domainNameServiceHost string) err
 	return nil
 }
 
-func NewClient[Client any](ctx context.Context, connWrapper ConnWrapper[Client], serviceName string) (Client, error) {
-	conn, err := grpc.DialContext(
-		ctx,
-		serviceName,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+func NewClient[Client any](ctx context.Context, connWrapper ConnWrapper[Client], serviceName string, options ...ClientOption) (Client, error) {
+	conn, err := dial(ctx, serviceName, options...)
 	if err != nil {
 		return lo.Empty[Client](), err
 	}
 
 	return connWrapper(conn), nil
 }
+
+func dial(ctx context.Context, target string, options ...ClientOption) (*grpc.ClientConn, error) {
+	config := &clientConfig{
+		transportCredentials: insecure.NewCredentials(),
+		loadBalancing:        RoundRobin,
+	}
+
+	for _, opt := range options {
+		err := opt(config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	serviceConfig, err := config.serviceConfig()
+	if err != nil {
+		return nil, fmt.Errorf("serviceConfig: %w", err)
+	}
+
+	unaryInterceptors, streamInterceptors := config.interceptors(target)
+
+	dialOptions := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(config.transportCredentials),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithChainUnaryInterceptor(unaryInterceptors...),
+		grpc.WithChainStreamInterceptor(streamInterceptors...),
+	}, config.dialOptions...)
+
+	return grpc.DialContext(ctx, target, dialOptions...)
+}
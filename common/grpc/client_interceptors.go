@@ -0,0 +1,318 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// RetryPolicy is applied by grpc to every method of the client, see
+// https://github.com/grpc/proposal/blob/master/A6-client-retries.md
+type RetryPolicy struct {
+	// Including the first attempt, grpc limits it to 5. Default 3
+	MaxAttempts int
+	// Default 100ms
+	InitialBackoff time.Duration
+	// Default 1s
+	MaxBackoff time.Duration
+	// Default 2
+	BackoffMultiplier float64
+	// Default codes.Unavailable
+	RetryableCodes []codes.Code
+}
+
+type (
+	serviceConfig struct {
+		LoadBalancingConfig []map[string]struct{} `json:"loadBalancingConfig,omitempty"`
+		MethodConfig        []methodConfig        `json:"methodConfig,omitempty"`
+	}
+
+	methodConfig struct {
+		Name        []methodName       `json:"name"`
+		RetryPolicy *retryPolicyConfig `json:"retryPolicy,omitempty"`
+	}
+
+	methodName struct {
+		Service string `json:"service,omitempty"`
+		Method  string `json:"method,omitempty"`
+	}
+
+	retryPolicyConfig struct {
+		MaxAttempts          int          `json:"maxAttempts"`
+		InitialBackoff       string       `json:"initialBackoff"`
+		MaxBackoff           string       `json:"maxBackoff"`
+		BackoffMultiplier    float64      `json:"backoffMultiplier"`
+		RetryableStatusCodes []codes.Code `json:"retryableStatusCodes"`
+	}
+)
+
+// WithClientMetrics collects requests count, status codes and latency of outgoing calls.
+func WithClientMetrics() ClientOption {
+	return func(c *clientConfig) error {
+		c.metrics = true
+		return nil
+	}
+}
+
+// WithClientTracing starts a client span for every call and propagates it to the server, WithTracer is required.
+func WithClientTracing() ClientOption {
+	return func(c *clientConfig) error {
+		c.tracing = true
+		return nil
+	}
+}
+
+// WithDefaultTimeout sets deadline for calls which context has no deadline.
+func WithDefaultTimeout(timeout time.Duration) ClientOption {
+	return func(c *clientConfig) error {
+		if timeout <= 0 {
+			return fmt.Errorf("default timeout must be positive, got %s", timeout)
+		}
+
+		c.defaultTimeout = timeout
+		return nil
+	}
+}
+
+// WithRetryPolicy retries failed calls with exponential backoff through grpc service config.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *clientConfig) error {
+		if policy.MaxAttempts == 0 {
+			policy.MaxAttempts = 3
+		}
+
+		if policy.MaxAttempts < 2 {
+			return fmt.Errorf("retry policy max attempts must be at least 2, got %d", policy.MaxAttempts)
+		}
+
+		if policy.InitialBackoff == 0 {
+			policy.InitialBackoff = 100 * time.Millisecond
+		}
+
+		if policy.MaxBackoff == 0 {
+			policy.MaxBackoff = time.Second
+		}
+
+		if policy.BackoffMultiplier == 0 {
+			policy.BackoffMultiplier = 2
+		}
+
+		if len(policy.RetryableCodes) == 0 {
+			policy.RetryableCodes = []codes.Code{codes.Unavailable}
+		}
+
+		c.retryPolicy = &policy
+		return nil
+	}
+}
+
+func (c *clientConfig) interceptors(target string) ([]grpc.UnaryClientInterceptor, []grpc.StreamClientInterceptor) {
+	var (
+		unaryInterceptors  []grpc.UnaryClientInterceptor
+		streamInterceptors []grpc.StreamClientInterceptor
+	)
+
+	if c.metrics {
+		unaryInterceptors = append(unaryInterceptors, clientMetricsInterceptor(target))
+		streamInterceptors = append(streamInterceptors, streamClientMetricsInterceptor(target))
+	}
+
+	// the tracer may be created after the client, it is checked on every call
+	if c.tracing {
+		unaryInterceptors = append(unaryInterceptors, clientTracingInterceptor())
+		streamInterceptors = append(streamInterceptors, streamClientTracingInterceptor())
+	}
+
+	if c.circuitBreaker != nil {
+		breakers := newCircuitBreakers(target, *c.circuitBreaker)
+		unaryInterceptors = append(unaryInterceptors, clientCircuitBreakerInterceptor(breakers))
+		streamInterceptors = append(streamInterceptors, streamClientCircuitBreakerInterceptor(breakers))
+	}
+
+	if c.bulkheadSize > 0 {
+		// unary calls and streams share the limit
+		slots := make(chan struct{}, c.bulkheadSize)
+		unaryInterceptors = append(unaryInterceptors, clientBulkheadInterceptor(target, slots, c.bulkheadWait))
+		streamInterceptors = append(streamInterceptors, streamClientBulkheadInterceptor(target, slots, c.bulkheadWait))
+	}
+
+	if c.defaultTimeout > 0 {
+		unaryInterceptors = append(unaryInterceptors, clientTimeoutInterceptor(c.defaultTimeout))
+	}
+
+	return unaryInterceptors, streamInterceptors
+}
+
+// serviceConfig returns default service config, it is used if the resolver doesn't provide one.
+func (c *clientConfig) serviceConfig() (string, error) {
+	config := serviceConfig{
+		LoadBalancingConfig: []map[string]struct{}{{c.loadBalancing: {}}},
+	}
+
+	if c.retryPolicy != nil {
+		config.MethodConfig = append(config.MethodConfig, methodConfig{
+			// empty name matches every method
+			Name: []methodName{{}},
+			RetryPolicy: &retryPolicyConfig{
+				MaxAttempts:          c.retryPolicy.MaxAttempts,
+				InitialBackoff:       fmt.Sprintf("%gs", c.retryPolicy.InitialBackoff.Seconds()),
+				MaxBackoff:           fmt.Sprintf("%gs", c.retryPolicy.MaxBackoff.Seconds()),
+				BackoffMultiplier:    c.retryPolicy.BackoffMultiplier,
+				RetryableStatusCodes: c.retryPolicy.RetryableCodes,
+			},
+		})
+	}
+
+	bytes, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	return string(bytes), nil
+}
+
+func clientMetricsInterceptor(target string) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		done := metrics.Load().clientStartedRPC(target, grpcTypeUnary, method)
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		done(err)
+
+		return err
+	}
+}
+
+func streamClientMetricsInterceptor(target string) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		rpcType := grpcType(desc.ClientStreams, desc.ServerStreams)
+		done := metrics.Load().clientStartedRPC(target, rpcType, method)
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			done(err)
+			return nil, err
+		}
+
+		return &monitoredClientStream{
+			ClientStream: stream,
+			desc:         desc,
+			target:       target,
+			rpcType:      rpcType,
+			fullMethod:   method,
+			done:         done,
+		}, nil
+	}
+}
+
+func clientTracingInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if loadTracer() == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		ctx, span := startClientSpan(ctx, method)
+		defer span.End()
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		setSpanStatus(span, err)
+
+		return err
+	}
+}
+
+func streamClientTracingInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		if loadTracer() == nil {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		ctx, span := startClientSpan(ctx, method)
+		// span covers stream creation only, stream lifetime is controlled by the caller
+		defer span.End()
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		setSpanStatus(span, err)
+
+		return stream, err
+	}
+}
+
+// startClientSpan starts a span as a child of the current server span
+// and replaces trace context in outgoing metadata, so the called server continues the trace from it.
+func startClientSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	// trace context put to outgoing metadata by hand
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		if md, ok := metadata.FromOutgoingContext(ctx); ok {
+			ctx = extractTraceContext(ctx, md)
+		}
+	}
+
+	service, name := splitFullMethod(method)
+
+	ctx, span := loadTracer().Start(
+		ctx,
+		method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.RPCSystemGRPC,
+			semconv.RPCService(service),
+			semconv.RPCMethod(name),
+		),
+	)
+
+	return injectOutgoingTraceContext(ctx), span
+}
+
+func clientTimeoutInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
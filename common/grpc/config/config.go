@@ -2,6 +2,7 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/fidesy/sdk/common/logger"
 	desc "github.com/fidesy/sdk/services/realtime-configs-service/pkg/realtime-configs-service"
@@ -14,6 +15,11 @@ type Value struct {
 }
 
 func GetValue(ctx context.Context, key string) Value {
+	if realtimeConfigsServiceClient == nil {
+		logger.Errorf("GetValue: %v", errors.New("realtime-configs-service client is not initialized"))
+		return Value{}
+	}
+
 	resp, err := realtimeConfigsServiceClient.GetValue(ctx, &desc.GetValueRequest{
 		Key:         key,
 		ServiceName: appName,
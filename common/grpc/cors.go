@@ -0,0 +1,173 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fidesy/sdk/common/grpc/config"
+	"github.com/fidesy/sdk/common/logger"
+	"github.com/rs/cors"
+	"go.uber.org/zap"
+)
+
+const defaultCORSRefreshInterval = 10 * time.Second
+
+type (
+	CORSConfig struct {
+		// "https://app.example.com", "https://*.example.com" for subdomains or "*" for any origin
+		AllowedOrigins []string
+		// Default GET, POST, PUT, PATCH, DELETE, HEAD
+		AllowedMethods []string
+		// Default Accept, Content-Type, Authorization, X-Api-Key, X-Request-Id and trace context headers
+		AllowedHeaders []string
+		// Response headers readable by the browser
+		ExposedHeaders   []string
+		AllowCredentials bool
+		// How long preflight responses are cached. Default 10m
+		MaxAge time.Duration
+
+		// realtime-configs-service key with comma separated origins replacing AllowedOrigins live,
+		// WithRealtimeConfigsService is required.
+		OriginsConfigKey string
+		// Default 10s
+		RefreshInterval time.Duration
+	}
+
+	corsPolicy struct {
+		config  CORSConfig
+		origins atomic.Pointer[[]string]
+	}
+)
+
+// WithCORS allows browsers to call the gateway from other origins, without it cross-origin requests are not allowed.
+func WithCORS(config CORSConfig) ServerOption {
+	return func(s *Server) error {
+		if len(config.AllowedMethods) == 0 {
+			config.AllowedMethods = []string{
+				http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead,
+			}
+		}
+
+		if len(config.AllowedHeaders) == 0 {
+			config.AllowedHeaders = append([]string{
+				"Accept", "Content-Type", "Authorization", "X-Api-Key", requestIDHeader,
+			}, propagationHeaders...)
+		}
+
+		if config.MaxAge == 0 {
+			config.MaxAge = 10 * time.Minute
+		}
+
+		if config.RefreshInterval == 0 {
+			config.RefreshInterval = defaultCORSRefreshInterval
+		}
+
+		if err := validateOrigins(config.AllowedOrigins, config.AllowCredentials); err != nil {
+			return err
+		}
+
+		policy := &corsPolicy{
+			config: config,
+		}
+		policy.origins.Store(&config.AllowedOrigins)
+
+		s.cors = policy
+		return nil
+	}
+}
+
+func (p *corsPolicy) handler(next http.Handler) http.Handler {
+	return cors.New(cors.Options{
+		AllowOriginFunc:  p.allowOrigin,
+		AllowedMethods:   p.config.AllowedMethods,
+		AllowedHeaders:   p.config.AllowedHeaders,
+		ExposedHeaders:   p.config.ExposedHeaders,
+		AllowCredentials: p.config.AllowCredentials,
+		MaxAge:           int(p.config.MaxAge.Seconds()),
+	}).Handler(next)
+}
+
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	return slices.ContainsFunc(*p.origins.Load(), func(pattern string) bool {
+		return matchOrigin(pattern, origin)
+	})
+}
+
+// run refreshes allowed origins from realtime-configs-service until ctx is done.
+func (p *corsPolicy) run(ctx context.Context) {
+	ticker := time.NewTicker(p.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		p.refreshOrigins(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *corsPolicy) refreshOrigins(ctx context.Context) {
+	value := config.GetValue(ctx, p.config.OriginsConfigKey).String()
+	if value == "" {
+		return
+	}
+
+	var origins []string
+	for _, origin := range strings.Split(value, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	if err := validateOrigins(origins, p.config.AllowCredentials); err != nil {
+		logger.Errorf("cors: %v", err, zap.String("config_key", p.config.OriginsConfigKey))
+		return
+	}
+
+	if slices.Equal(origins, *p.origins.Load()) {
+		return
+	}
+
+	logger.Info(fmt.Sprintf("cors allowed origins are updated: %s", strings.Join(origins, ", ")))
+	p.origins.Store(&origins)
+}
+
+func validateOrigins(origins []string, allowCredentials bool) error {
+	for _, origin := range origins {
+		if origin == "*" && allowCredentials {
+			return errors.New("cors origin * can't be used with credentials")
+		}
+
+		if strings.Count(origin, "*") > 1 {
+			return fmt.Errorf("cors origin %s has more than one wildcard", origin)
+		}
+	}
+
+	return nil
+}
+
+// matchOrigin matches origin by pattern with at most one wildcard.
+func matchOrigin(pattern, origin string) bool {
+	if pattern == "*" {
+		return true
+	}
+
+	prefix, suffix, wildcard := strings.Cut(strings.ToLower(pattern), "*")
+	origin = strings.ToLower(origin)
+	if !wildcard {
+		return origin == prefix
+	}
+
+	return len(origin) > len(prefix)+len(suffix) &&
+		strings.HasPrefix(origin, prefix) &&
+		strings.HasSuffix(origin, suffix)
+}
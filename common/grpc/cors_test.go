@@ -0,0 +1,155 @@
+package grpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		origin  string
+		want    bool
+	}{
+		{
+			name:    "any origin",
+			pattern: "*",
+			origin:  "https://example.com",
+			want:    true,
+		},
+		{
+			name:    "exact origin",
+			pattern: "https://example.com",
+			origin:  "https://example.com",
+			want:    true,
+		},
+		{
+			name:    "case insensitive",
+			pattern: "https://Example.com",
+			origin:  "https://EXAMPLE.COM",
+			want:    true,
+		},
+		{
+			name:    "another scheme",
+			pattern: "https://example.com",
+			origin:  "http://example.com",
+			want:    false,
+		},
+		{
+			name:    "another port",
+			pattern: "https://example.com",
+			origin:  "https://example.com:8080",
+			want:    false,
+		},
+		{
+			name:    "subdomain wildcard",
+			pattern: "https://*.example.com",
+			origin:  "https://app.example.com",
+			want:    true,
+		},
+		{
+			name:    "nested subdomain wildcard",
+			pattern: "https://*.example.com",
+			origin:  "https://api.app.example.com",
+			want:    true,
+		},
+		{
+			name:    "wildcard doesn't match the domain itself",
+			pattern: "https://*.example.com",
+			origin:  "https://example.com",
+			want:    false,
+		},
+		{
+			name:    "wildcard must match something",
+			pattern: "https://*.example.com",
+			origin:  "https://.example.com",
+			want:    false,
+		},
+		{
+			name:    "wildcard doesn't match a domain with the suffix",
+			pattern: "https://*.example.com",
+			origin:  "https://app.example.com.evil.com",
+			want:    false,
+		},
+		{
+			name:    "wildcard doesn't match a domain ending with the name",
+			pattern: "https://*.example.com",
+			origin:  "https://evilexample.com",
+			want:    false,
+		},
+		{
+			name:    "wildcard keeps the scheme",
+			pattern: "https://*.example.com",
+			origin:  "http://app.example.com",
+			want:    false,
+		},
+		{
+			name:    "port wildcard",
+			pattern: "http://localhost:*",
+			origin:  "http://localhost:3000",
+			want:    true,
+		},
+		{
+			name:    "port wildcard without port",
+			pattern: "http://localhost:*",
+			origin:  "http://localhost",
+			want:    false,
+		},
+		{
+			name:    "empty origin",
+			pattern: "https://*.example.com",
+			origin:  "",
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, matchOrigin(tt.pattern, tt.origin))
+		})
+	}
+}
+
+func TestValidateOrigins(t *testing.T) {
+	tests := []struct {
+		name             string
+		origins          []string
+		allowCredentials bool
+		wantErr          bool
+	}{
+		{
+			name:    "any origin",
+			origins: []string{"*"},
+		},
+		{
+			name:             "any origin with credentials",
+			origins:          []string{"*"},
+			allowCredentials: true,
+			wantErr:          true,
+		},
+		{
+			name:             "wildcard with credentials",
+			origins:          []string{"https://example.com", "https://*.example.com"},
+			allowCredentials: true,
+		},
+		{
+			name:    "more than one wildcard",
+			origins: []string{"https://*.example.*"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOrigins(tt.origins, tt.allowCredentials)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// gatewayNetwork is the network of in-process gateway connections.
+const gatewayNetwork = "gateway"
+
+type (
+	// GatewayRegistrar is Register...Handler generated by protoc-gen-grpc-gateway.
+	GatewayRegistrar func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error
+
+	// GatewayDescriptor is optionally implemented by ServiceDescriptor to serve its REST handlers
+	// on the proxy port, the handlers are registered by Server.Run.
+	GatewayDescriptor interface {
+		GetGatewayRegistrar() GatewayRegistrar
+	}
+
+	// gatewayCredentials accepts in-process gateway connections without TLS handshake.
+	gatewayCredentials struct {
+		credentials.TransportCredentials
+	}
+
+	gatewayAuthInfo struct {
+		credentials.CommonAuthInfo
+	}
+
+	// gatewayListener accepts in-memory connections of the gateway client.
+	gatewayListener struct {
+		conns     chan net.Conn
+		done      chan struct{}
+		closeOnce sync.Once
+	}
+
+	gatewayConn struct {
+		net.Conn
+	}
+
+	gatewayAddr struct{}
+)
+
+// WithGatewayMarshaler sets marshaler for the MIME type, runtime.MIMEWildcard replaces the default one.
+func WithGatewayMarshaler(mime string, marshaler runtime.Marshaler) ServerOption {
+	return func(s *Server) error {
+		s.gatewayOptions = append(s.gatewayOptions, runtime.WithMarshalerOption(mime, marshaler))
+		return nil
+	}
+}
+
+// WithGatewayHeaders forwards HTTP request headers to grpc metadata as is.
+func WithGatewayHeaders(headers ...string) ServerOption {
+	return func(s *Server) error {
+		s.proxyHeaders = append(s.proxyHeaders, headers...)
+		return nil
+	}
+}
+
+// WithGatewayErrorHandler replaces GatewayErrorHandler writing grpc errors to HTTP responses.
+func WithGatewayErrorHandler(handler runtime.ErrorHandlerFunc) ServerOption {
+	return func(s *Server) error {
+		s.gatewayOptions = append(s.gatewayOptions, runtime.WithErrorHandler(handler))
+		return nil
+	}
+}
+
+// WithGatewayOptions passes raw options to runtime.NewServeMux.
+func WithGatewayOptions(options ...runtime.ServeMuxOption) ServerOption {
+	return func(s *Server) error {
+		s.gatewayOptions = append(s.gatewayOptions, options...)
+		return nil
+	}
+}
+
+// gatewayRegistrars returns registrars of services when the gateway is enabled.
+func (s *Server) gatewayRegistrars(descs []ServiceDescriptor) []GatewayRegistrar {
+	if s.proxyRouter == nil {
+		return nil
+	}
+
+	var registrars []GatewayRegistrar
+	for _, desc := range descs {
+		if gatewayDesc, ok := desc.(GatewayDescriptor); ok {
+			registrars = append(registrars, gatewayDesc.GetGatewayRegistrar())
+		}
+	}
+
+	return registrars
+}
+
+// registerGateway registers REST handlers calling grpcServer through in-memory connection,
+// so the calls pass server interceptors without network round trip. The connection must be closed by the caller.
+func (s *Server) registerGateway(
+	ctx context.Context,
+	grpcServer *grpc.Server,
+	registrars []GatewayRegistrar,
+) (*grpc.ClientConn, net.Listener, error) {
+	lis := newGatewayListener()
+
+	conn, err := grpc.DialContext(
+		ctx,
+		"passthrough:///gateway",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("grpc.DialContext: %w", err)
+	}
+
+	for _, register := range registrars {
+		if err = register(ctx, s.proxyRouter, conn); err != nil {
+			_ = conn.Close()
+			return nil, nil, fmt.Errorf("register gateway: %w", err)
+		}
+	}
+
+	return conn, lis, nil
+}
+
+func (c gatewayCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	if conn.LocalAddr().Network() == gatewayNetwork {
+		return conn, gatewayAuthInfo{
+			CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.NoSecurity},
+		}, nil
+	}
+
+	return c.TransportCredentials.ServerHandshake(conn)
+}
+
+func (c gatewayCredentials) Clone() credentials.TransportCredentials {
+	return gatewayCredentials{TransportCredentials: c.TransportCredentials.Clone()}
+}
+
+func (gatewayAuthInfo) AuthType() string {
+	return "gateway"
+}
+
+func newGatewayListener() *gatewayListener {
+	return &gatewayListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+func (l *gatewayListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *gatewayListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.done)
+	})
+
+	return nil
+}
+
+func (l *gatewayListener) Addr() net.Addr {
+	return gatewayAddr{}
+}
+
+// DialContext returns the client side of a pipe, the server side is returned by Accept.
+func (l *gatewayListener) DialContext(ctx context.Context) (net.Conn, error) {
+	serverConn, clientConn := net.Pipe()
+
+	select {
+	case l.conns <- gatewayConn{Conn: serverConn}:
+		return gatewayConn{Conn: clientConn}, nil
+	case <-l.done:
+		_ = serverConn.Close()
+		_ = clientConn.Close()
+		return nil, net.ErrClosed
+	case <-ctx.Done():
+		_ = serverConn.Close()
+		_ = clientConn.Close()
+		return nil, ctx.Err()
+	}
+}
+
+func (gatewayConn) LocalAddr() net.Addr {
+	return gatewayAddr{}
+}
+
+func (gatewayConn) RemoteAddr() net.Addr {
+	return gatewayAddr{}
+}
+
+func (gatewayAddr) Network() string {
+	return gatewayNetwork
+}
+
+func (gatewayAddr) String() string {
+	return gatewayNetwork
+}
@@ -0,0 +1,154 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/fidesy/sdk/common/logger"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const requestIDHeader = "x-request-id"
+
+// GatewayError is the body of failed gateway responses.
+type GatewayError struct {
+	// grpc code name, e.g. INVALID_ARGUMENT
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// ErrorInfo reason if the handler provided it
+	Reason string `json:"reason,omitempty"`
+	// google.rpc error details with @type
+	Details   []json.RawMessage `json:"details,omitempty"`
+	TraceID   string            `json:"trace_id,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+}
+
+// GatewayErrorHandler writes grpc error as GatewayError. Besides the default code mapping
+// BadRequest details make it 400 and RetryInfo sets Retry-After header.
+func GatewayErrorHandler(
+	ctx context.Context,
+	_ *runtime.ServeMux,
+	_ runtime.Marshaler,
+	w http.ResponseWriter,
+	r *http.Request,
+	err error,
+) {
+	httpStatus := 0
+
+	var statusErr *runtime.HTTPStatusError
+	if errors.As(err, &statusErr) {
+		err = statusErr.Err
+		httpStatus = statusErr.HTTPStatus
+	}
+
+	st := status.Convert(err)
+	if httpStatus == 0 {
+		httpStatus = runtime.HTTPStatusFromCode(st.Code())
+	}
+
+	body := GatewayError{
+		Code:      code.Code_name[int32(st.Code())],
+		Message:   st.Message(),
+		TraceID:   gatewayTraceID(ctx, r),
+		RequestID: r.Header.Get(requestIDHeader),
+	}
+
+	md, hasMetadata := runtime.ServerMetadataFromContext(ctx)
+	if values := md.HeaderMD.Get(requestIDHeader); len(values) > 0 {
+		body.RequestID = values[0]
+	}
+
+	for _, detail := range st.Proto().GetDetails() {
+		bytes, err := protojson.Marshal(detail)
+		if err != nil {
+			logger.Errorf("protojson.Marshal: %v", err)
+			continue
+		}
+
+		body.Details = append(body.Details, bytes)
+	}
+
+	for _, detail := range st.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.BadRequest:
+			httpStatus = http.StatusBadRequest
+		case *errdetails.RetryInfo:
+			seconds := int64(math.Ceil(detail.GetRetryDelay().AsDuration().Seconds()))
+			w.Header().Set("Retry-After", strconv.FormatInt(max(seconds, 1), 10))
+		case *errdetails.ErrorInfo:
+			body.Reason = detail.GetReason()
+		}
+	}
+
+	if hasMetadata {
+		for key, values := range md.HeaderMD {
+			header, _ := proxyOutgoingHeaderMatcher(key)
+			for _, value := range values {
+				w.Header().Add(header, value)
+			}
+		}
+	}
+
+	writeGatewayError(w, httpStatus, body)
+}
+
+// GatewayRoutingErrorHandler writes GatewayError for requests matching no handler, the HTTP status is kept.
+func GatewayRoutingErrorHandler(
+	ctx context.Context,
+	_ *runtime.ServeMux,
+	_ runtime.Marshaler,
+	w http.ResponseWriter,
+	r *http.Request,
+	httpStatus int,
+) {
+	grpcCode := codes.Internal
+	message := "unexpected routing error"
+
+	switch httpStatus {
+	case http.StatusNotFound:
+		grpcCode, message = codes.NotFound, "no handler for "+r.URL.Path
+	case http.StatusMethodNotAllowed:
+		grpcCode, message = codes.Unimplemented, r.Method+" is not allowed for "+r.URL.Path
+	case http.StatusBadRequest:
+		grpcCode, message = codes.InvalidArgument, "bad request"
+	default:
+		httpStatus = http.StatusInternalServerError
+	}
+
+	writeGatewayError(w, httpStatus, GatewayError{
+		Code:      code.Code_name[int32(grpcCode)],
+		Message:   message,
+		TraceID:   gatewayTraceID(ctx, r),
+		RequestID: r.Header.Get(requestIDHeader),
+	})
+}
+
+func writeGatewayError(w http.ResponseWriter, httpStatus int, body GatewayError) {
+	w.Header().Del("Trailer")
+	w.Header().Del("Transfer-Encoding")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Errorf("json.Encode: %v", err)
+	}
+}
+
+// gatewayTraceID returns trace id of the request, the grpc span is a child of it.
+func gatewayTraceID(ctx context.Context, r *http.Request) string {
+	if traceID := traceIDFromContext(ctx); traceID != "" {
+		return traceID
+	}
+
+	return traceIDFromContext(propagator.Extract(ctx, propagation.HeaderCarrier(r.Header)))
+}
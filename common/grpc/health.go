@@ -0,0 +1,217 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	domain_name_service "github.com/fidesy/sdk/common/grpc/pkg/domain-name-service"
+	"github.com/fidesy/sdk/common/logger"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	healthCheckTimeout  = 2 * time.Second
+	healthCheckInterval = 5 * time.Second
+)
+
+// HealthCheck reports whether a service dependency is available, a non-nil error makes the service not ready.
+type HealthCheck func(ctx context.Context) error
+
+type healthCheckResult struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// WithHealthCheck adds a named check which is taken into account by /readyz and grpc.health.v1.
+func WithHealthCheck(name string, check HealthCheck) ServerOption {
+	return func(s *Server) error {
+		if _, ok := s.healthChecks[name]; ok {
+			return fmt.Errorf("health check %q is already registered", name)
+		}
+
+		s.healthChecks[name] = check
+		return nil
+	}
+}
+
+func PostgresHealthCheck(pool *pgxpool.Pool) HealthCheck {
+	return func(ctx context.Context) error {
+		if err := pool.Ping(ctx); err != nil {
+			return fmt.Errorf("pool.Ping: %w", err)
+		}
+
+		return nil
+	}
+}
+
+func RedisHealthCheck(client *redis.Client) HealthCheck {
+	return func(ctx context.Context) error {
+		if err := client.Ping(ctx).Err(); err != nil {
+			return fmt.Errorf("redis.Ping: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// KafkaReaderHealthCheck checks that at least one broker of the reader is reachable.
+func KafkaReaderHealthCheck(reader *kafka.Reader) HealthCheck {
+	return func(ctx context.Context) error {
+		config := reader.Config()
+
+		dialer := config.Dialer
+		if dialer == nil {
+			dialer = kafka.DefaultDialer
+		}
+
+		var errs []error
+		for _, broker := range config.Brokers {
+			conn, err := dialer.DialContext(ctx, "tcp", broker)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("dialer.DialContext %s: %w", broker, err))
+				continue
+			}
+
+			return conn.Close()
+		}
+
+		return errors.Join(errs...)
+	}
+}
+
+// DomainNameServiceHealthCheck checks that domain-name-service is reachable, the service itself may be not registered yet.
+func DomainNameServiceHealthCheck() HealthCheck {
+	return func(ctx context.Context) error {
+		if domainNameServiceClient == nil {
+			return errors.New("domain-name-service is not configured")
+		}
+
+		_, err := domainNameServiceClient.GetAddress(ctx, &domain_name_service.GetAddressRequest{
+			ServiceName: appName,
+		})
+		if err != nil && status.Code(err) != codes.NotFound {
+			return fmt.Errorf("domainNameServiceClient.GetAddress: %w", err)
+		}
+
+		return nil
+	}
+}
+
+func (s *Server) registerHealthServer(grpcServer *grpc.Server) {
+	s.healthServer = health.NewServer()
+	s.healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	for name := range grpcServer.GetServiceInfo() {
+		s.healthServer.SetServingStatus(name, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+
+	healthpb.RegisterHealthServer(grpcServer, s.healthServer)
+}
+
+// watchHealth keeps grpc.health.v1 statuses in sync with readiness until ctx is done.
+func (s *Server) watchHealth(ctx context.Context, grpcServer *grpc.Server) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	// status is updated as soon as the server is ready, not on the next tick
+	readyCh := s.readyCh
+
+	for {
+		servingStatus := healthpb.HealthCheckResponse_NOT_SERVING
+		if s.checkReadiness(ctx).Status == "ok" {
+			servingStatus = healthpb.HealthCheckResponse_SERVING
+		}
+
+		s.healthServer.SetServingStatus("", servingStatus)
+		for name := range grpcServer.GetServiceInfo() {
+			s.healthServer.SetServingStatus(name, servingStatus)
+		}
+
+		s.heartbeat(ctx, servingStatus == healthpb.HealthCheckResponse_SERVING)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-readyCh:
+			readyCh = nil
+		}
+	}
+}
+
+func (s *Server) checkReadiness(ctx context.Context) healthCheckResult {
+	result := healthCheckResult{
+		Status: "ok",
+		Checks: make(map[string]string, len(s.healthChecks)),
+	}
+
+	if !s.ready.Load() {
+		result.Status = "not ready"
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for name, check := range s.healthChecks {
+		wg.Add(1)
+		go func(name string, check HealthCheck) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			checkStatus := "ok"
+			if err := check(checkCtx); err != nil {
+				logger.Errorf("health check: %v", err, zap.String("check", name))
+				checkStatus = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			result.Checks[name] = checkStatus
+			if checkStatus != "ok" {
+				result.Status = "not ready"
+			}
+		}(name, check)
+	}
+
+	wg.Wait()
+
+	return result
+}
+
+func livenessHandler(w http.ResponseWriter, _ *http.Request) {
+	writeHealthCheckResult(w, healthCheckResult{Status: "ok"})
+}
+
+func (s *Server) readinessHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealthCheckResult(w, s.checkReadiness(r.Context()))
+}
+
+func writeHealthCheckResult(w http.ResponseWriter, result healthCheckResult) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if result.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		logger.Errorf("json.Encode: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package grpc
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type (
+	HTTPTimeouts struct {
+		// Default 10s
+		ReadHeader time.Duration
+		// Whole request including body. Default 30s
+		Read time.Duration
+		// Default 30s
+		Write time.Duration
+		// Keep-alive connections without requests. Default 2m
+		Idle time.Duration
+	}
+
+	adminHandler struct {
+		pattern string
+		handler http.Handler
+	}
+)
+
+var defaultHTTPTimeouts = HTTPTimeouts{
+	ReadHeader: 10 * time.Second,
+	Read:       30 * time.Second,
+	Write:      30 * time.Second,
+	Idle:       2 * time.Minute,
+}
+
+// WithHTTPTimeouts sets timeouts of metrics, proxy and swagger servers. Write timeout isn't applied
+// to metrics server because of pprof, in single port mode only ReadHeader and Idle are applied,
+// as read and write timeouts would break grpc streams.
+func WithHTTPTimeouts(timeouts HTTPTimeouts) ServerOption {
+	return func(s *Server) error {
+		if timeouts.ReadHeader > 0 {
+			s.httpTimeouts.ReadHeader = timeouts.ReadHeader
+		}
+
+		if timeouts.Read > 0 {
+			s.httpTimeouts.Read = timeouts.Read
+		}
+
+		if timeouts.Write > 0 {
+			s.httpTimeouts.Write = timeouts.Write
+		}
+
+		if timeouts.Idle > 0 {
+			s.httpTimeouts.Idle = timeouts.Idle
+		}
+
+		return nil
+	}
+}
+
+// WithAdminHandler mounts handler on the metrics port, see Server.HandleAdmin.
+func WithAdminHandler(pattern string, handler http.Handler) ServerOption {
+	return func(s *Server) error {
+		s.HandleAdmin(pattern, handler)
+		return nil
+	}
+}
+
+// HandleAdmin mounts handler on the metrics port (or the single port), e.g. to expose internal state.
+// It must be called before Server.Run.
+func (s *Server) HandleAdmin(pattern string, handler http.Handler) {
+	s.adminHandlers = append(s.adminHandlers, adminHandler{pattern: pattern, handler: handler})
+}
+
+// newHTTPServer returns server with its own handler, http.DefaultServeMux is never used.
+func (s *Server) newHTTPServer(port string, handler http.Handler) *http.Server {
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%s", port),
+		Handler:           handler,
+		ReadHeaderTimeout: s.httpTimeouts.ReadHeader,
+		ReadTimeout:       s.httpTimeouts.Read,
+		WriteTimeout:      s.httpTimeouts.Write,
+		IdleTimeout:       s.httpTimeouts.Idle,
+	}
+	s.httpServers = append(s.httpServers, server)
+
+	return server
+}
+
+func (s *Server) registerAdminHandlers(mux *http.ServeMux) {
+	s.registerDebugHandlers(mux)
+
+	for _, admin := range s.adminHandlers {
+		mux.Handle(admin.pattern, s.requireAdminToken(admin.handler))
+	}
+}
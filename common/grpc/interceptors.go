@@ -0,0 +1,89 @@
+package grpc
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// WithUnaryInterceptors adds unary interceptors to the server.
+// They are called in the given order after the SDK interceptors (metrics, tracing, recovery, auth, rate limit, validation),
+// so they see the trace context and the principal, their errors are counted in metrics and their panics are recovered.
+func WithUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) ServerOption {
+	return func(s *Server) error {
+		s.unaryInterceptors = append(s.unaryInterceptors, interceptors...)
+		return nil
+	}
+}
+
+// WithStreamInterceptors adds stream interceptors to the server,
+// ordering is the same as for WithUnaryInterceptors.
+func WithStreamInterceptors(interceptors ...grpc.StreamServerInterceptor) ServerOption {
+	return func(s *Server) error {
+		s.streamInterceptors = append(s.streamInterceptors, interceptors...)
+		return nil
+	}
+}
+
+// WithGRPCServerOptions passes raw options (keepalive, message size, compression, credentials) to grpc.NewServer.
+// Interceptors must be added with WithUnaryInterceptors and WithStreamInterceptors,
+// because grpc.UnaryInterceptor and grpc.StreamInterceptor can be set only once.
+func WithGRPCServerOptions(options ...grpc.ServerOption) ServerOption {
+	return func(s *Server) error {
+		s.serverOptions = append(s.serverOptions, options...)
+		return nil
+	}
+}
+
+func (s *Server) grpcServerOptions() []grpc.ServerOption {
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		metricsInterceptor(s.metrics),
+	}
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		streamMetricsInterceptor(s.metrics),
+	}
+	if loadTracer() != nil {
+		unaryInterceptors = append(unaryInterceptors, tracingInterceptor())
+		streamInterceptors = append(streamInterceptors, streamTracingInterceptor())
+	}
+	if s.accessLog != nil {
+		unaryInterceptors = append(unaryInterceptors, accessLogInterceptor(s.accessLog))
+		streamInterceptors = append(streamInterceptors, streamAccessLogInterceptor(s.accessLog))
+	}
+	if !s.disableRecovery {
+		unaryInterceptors = append(unaryInterceptors, recoveryInterceptor(s.recoveryHandler, s.metrics))
+		streamInterceptors = append(streamInterceptors, streamRecoveryInterceptor(s.recoveryHandler, s.metrics))
+	}
+	if s.authenticator != nil {
+		unaryInterceptors = append(unaryInterceptors, authInterceptor(s.authenticator, s.authPolicies))
+		streamInterceptors = append(streamInterceptors, streamAuthInterceptor(s.authenticator, s.authPolicies))
+	}
+	if s.rateLimiter != nil {
+		unaryInterceptors = append(unaryInterceptors, rateLimitInterceptor(s.rateLimiter))
+		streamInterceptors = append(streamInterceptors, streamRateLimitInterceptor(s.rateLimiter))
+	}
+	if !s.disableValidation {
+		unaryInterceptors = append(unaryInterceptors, validationInterceptor())
+		streamInterceptors = append(streamInterceptors, streamValidationInterceptor())
+	}
+
+	unaryInterceptors = append(unaryInterceptors, s.unaryInterceptors...)
+	streamInterceptors = append(streamInterceptors, s.streamInterceptors...)
+
+	options := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			unaryInterceptors...,
+		),
+		grpc.ChainStreamInterceptor(
+			streamInterceptors...,
+		),
+	}
+
+	if s.tlsConfig != nil {
+		// the gateway calls the server in-process without TLS
+		options = append(options, grpc.Creds(gatewayCredentials{
+			TransportCredentials: credentials.NewTLS(s.tlsConfig),
+		}))
+	}
+
+	return append(options, s.serverOptions...)
+}
@@ -2,102 +2,489 @@ package grpc
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"io"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	grpcResolver "github.com/fidesy/sdk/common/grpc/resolver"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/status"
 )
 
-var metricsAppName = strings.ReplaceAll(os.Getenv("APP_NAME"), "-", "_")
+const (
+	grpcTypeUnary        = "unary"
+	grpcTypeClientStream = "client_stream"
+	grpcTypeServerStream = "server_stream"
+	grpcTypeBidiStream   = "bidi_stream"
+)
 
-var reg *prometheus.Registry
+// reg is the registry of the last created server.
+var reg atomic.Pointer[prometheus.Registry]
 
-var (
-	requests = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: fmt.Sprintf("%s_grpc_requests", metricsAppName),
-			Help: "Count of grpc requests by handlers",
-		},
-		[]string{"handler"},
-	)
-	responseTime = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    fmt.Sprintf("%s_grpc_response_time", metricsAppName),
-			Help:    "Response time of grpc requests",
-			Buckets: []float64{0.1, 0.5, 1, 2, 5},
-		},
-		[]string{"handler"},
-	)
-	statusCodes = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: fmt.Sprintf("%s_status_codes", metricsAppName),
-			Help: "Handlers not successful status codes",
-		},
-		[]string{"handler", "status_code"},
-	)
+// metrics of clients are replaced by NewServer with the metrics of the server, clients created
+// by options before it load them on every call and use the configured ones since then.
+// Servers record their own metrics, so every server exports them in its registry.
+var metrics atomic.Pointer[grpcMetrics]
+
+func init() {
+	metrics.Store(newGRPCMetrics(MetricsConfig{}))
+}
+
+type (
+	MetricsConfig struct {
+		// Buckets of handling time histograms in seconds. Default prometheus.DefBuckets
+		Buckets []float64
+		// Enables native histograms with the bucket growth factor, e.g. 1.1.
+		// Classic buckets are exported too for servers without native histograms support.
+		NativeHistogramBucketFactor float64
+		// Labels added to every metric. Default service from APP_NAME and env from ENV
+		ConstLabels prometheus.Labels
+		// Exports metrics under the old names prefixed with the app name too, e.g. users_service_grpc_requests
+		LegacyNames bool
+	}
+
+	grpcMetrics struct {
+		serverStarted         *prometheus.CounterVec
+		serverHandled         *prometheus.CounterVec
+		serverHandlingSeconds *prometheus.HistogramVec
+		serverMsgReceived     *prometheus.CounterVec
+		serverMsgSent         *prometheus.CounterVec
+		serverInFlight        *prometheus.GaugeVec
+		serverPanics          *prometheus.CounterVec
+		serverRateLimited     *prometheus.CounterVec
+
+		clientStarted         *prometheus.CounterVec
+		clientHandled         *prometheus.CounterVec
+		clientHandlingSeconds *prometheus.HistogramVec
+		clientMsgReceived     *prometheus.CounterVec
+		clientMsgSent         *prometheus.CounterVec
+		clientInFlight        *prometheus.GaugeVec
+		clientRejected        *prometheus.CounterVec
+
+		clientCircuitBreakerState       *prometheus.GaugeVec
+		clientCircuitBreakerTransitions *prometheus.CounterVec
+
+		buildInfo *prometheus.GaugeVec
+
+		constLabels prometheus.Labels
+		legacy      *legacyMetrics
+	}
 )
 
-func initMetrics() {
-	reg = prometheus.NewRegistry()
+// WithMetrics configures grpc server and client metrics.
+func WithMetrics(config MetricsConfig) ServerOption {
+	return func(s *Server) error {
+		s.metricsConfig = config
+		return nil
+	}
+}
 
-	reg.MustRegister(
-		requests,
-		responseTime,
-		statusCodes,
-	)
+func newGRPCMetrics(config MetricsConfig) *grpcMetrics {
+	if len(config.Buckets) == 0 {
+		config.Buckets = prometheus.DefBuckets
+	}
+
+	if config.ConstLabels == nil {
+		config.ConstLabels = prometheus.Labels{
+			"service": appName,
+			"env":     strings.ToLower(os.Getenv("ENV")),
+		}
+	}
+
+	counter := func(name, help string, labels ...string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        name,
+			Help:        help,
+			ConstLabels: config.ConstLabels,
+		}, labels)
+	}
+
+	gauge := func(name, help string, labels ...string) *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        name,
+			Help:        help,
+			ConstLabels: config.ConstLabels,
+		}, labels)
+	}
+
+	histogram := func(name, help string, labels ...string) *prometheus.HistogramVec {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:                        name,
+			Help:                        help,
+			ConstLabels:                 config.ConstLabels,
+			Buckets:                     config.Buckets,
+			NativeHistogramBucketFactor: config.NativeHistogramBucketFactor,
+		}, labels)
+	}
+
+	serverLabels := []string{"grpc_type", "grpc_service", "grpc_method"}
+	clientLabels := []string{"grpc_target", "grpc_type", "grpc_service", "grpc_method"}
+
+	m := &grpcMetrics{
+		serverStarted: counter("grpc_server_started_total",
+			"Total number of RPCs started on the server", serverLabels...),
+		serverHandled: counter("grpc_server_handled_total",
+			"Total number of RPCs completed on the server, regardless of success or failure",
+			append(serverLabels, "grpc_code")...),
+		serverHandlingSeconds: histogram("grpc_server_handling_seconds",
+			"Response latency of RPCs handled by the server in seconds", serverLabels...),
+		serverMsgReceived: counter("grpc_server_msg_received_total",
+			"Total number of stream messages received from clients", serverLabels...),
+		serverMsgSent: counter("grpc_server_msg_sent_total",
+			"Total number of stream messages sent to clients", serverLabels...),
+		serverInFlight: gauge("grpc_server_in_flight_requests",
+			"Number of RPCs being handled by the server", serverLabels...),
+		serverPanics: counter("grpc_server_panics_recovered_total",
+			"Total number of panics recovered in handlers", "grpc_service", "grpc_method"),
+		serverRateLimited: counter("grpc_server_rate_limited_total",
+			"Total number of RPCs rejected by rate and concurrency limits", "grpc_service", "grpc_method", "limiter"),
+
+		clientStarted: counter("grpc_client_started_total",
+			"Total number of RPCs started by clients", clientLabels...),
+		clientHandled: counter("grpc_client_handled_total",
+			"Total number of RPCs completed by clients, regardless of success or failure",
+			append(clientLabels, "grpc_code")...),
+		clientHandlingSeconds: histogram("grpc_client_handling_seconds",
+			"Response latency of RPCs made by clients in seconds", clientLabels...),
+		clientMsgReceived: counter("grpc_client_msg_received_total",
+			"Total number of stream messages received from servers", clientLabels...),
+		clientMsgSent: counter("grpc_client_msg_sent_total",
+			"Total number of stream messages sent to servers", clientLabels...),
+		clientInFlight: gauge("grpc_client_in_flight_requests",
+			"Number of RPCs made by clients and not completed yet", clientLabels...),
+		clientRejected: counter("grpc_client_rejected_total",
+			"Total number of RPCs rejected by circuit breaker or bulkhead",
+			"grpc_target", "grpc_service", "grpc_method", "reason"),
+
+		clientCircuitBreakerState: gauge("grpc_client_circuit_breaker_state",
+			"Circuit breaker state: 0 - closed, 1 - half-open, 2 - open",
+			"grpc_target", "grpc_service", "grpc_method"),
+		clientCircuitBreakerTransitions: counter("grpc_client_circuit_breaker_transitions_total",
+			"Total number of circuit breaker transitions by the new state",
+			"grpc_target", "grpc_service", "grpc_method", "state"),
+
+		buildInfo: gauge("build_info",
+			"Always 1, labels describe the running binary", "version", "commit", "go_version"),
+
+		constLabels: config.ConstLabels,
+	}
+
+	if config.LegacyNames {
+		m.legacy = newLegacyMetrics()
+	}
+
+	return m
 }
 
-func runMetrics(ctx context.Context, port string) error {
-	// Create a new registry
-	http.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+func (m *grpcMetrics) collectors() []prometheus.Collector {
+	list := []prometheus.Collector{
+		m.serverStarted,
+		m.serverHandled,
+		m.serverHandlingSeconds,
+		m.serverMsgReceived,
+		m.serverMsgSent,
+		m.serverInFlight,
+		m.serverPanics,
+		m.serverRateLimited,
+		m.clientStarted,
+		m.clientHandled,
+		m.clientHandlingSeconds,
+		m.clientMsgReceived,
+		m.clientMsgSent,
+		m.clientInFlight,
+		m.clientRejected,
+		m.clientCircuitBreakerState,
+		m.clientCircuitBreakerTransitions,
+		m.buildInfo,
+	}
 
-	errChan := make(chan error)
-	go func() {
-		errChan <- http.ListenAndServe(fmt.Sprintf(":%s", port), nil)
-	}()
+	if m.legacy != nil {
+		list = append(list, m.legacy.collectors()...)
+	}
 
-	select {
-	case <-ctx.Done():
-		return nil
-	case err := <-errChan:
-		return err
+	return list
+}
+
+// initMetrics creates metrics of the server in its own registry, clients record to them since then.
+func (s *Server) initMetrics() {
+	s.metrics = newGRPCMetrics(s.metricsConfig)
+
+	s.registry = prometheus.NewRegistry()
+	s.registry.MustRegister(s.metrics.collectors()...)
+	s.registry.MustRegister(
+		grpcResolver.NewCollector(s.metrics.constLabels),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	info := buildInfo()
+	s.metrics.buildInfo.WithLabelValues(info["version"], info["commit"], info["go_version"]).Set(1)
+
+	if s.rateLimiter != nil {
+		s.rateLimiter.metrics = s.metrics
 	}
+
+	reg.Store(s.registry)
+	metrics.Store(s.metrics)
 }
 
+func (s *Server) registerMetricsHandlers(mux *http.ServeMux) {
+	mux.Handle("/metrics", promhttp.HandlerFor(s.registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/healthz", livenessHandler)
+	mux.HandleFunc("/readyz", s.readinessHandler)
+}
+
+// Registry returns the registry exported by /metrics of the server.
+func (s *Server) Registry() *prometheus.Registry {
+	return s.registry
+}
+
+// GetRegistry returns the registry of the last created server, use Server.Registry with several servers.
 func GetRegistry() *prometheus.Registry {
-	return reg
+	return reg.Load()
 }
 
-func metricsInterceptor() grpc.UnaryServerInterceptor {
+func grpcType(clientStream, serverStream bool) string {
+	switch {
+	case clientStream && serverStream:
+		return grpcTypeBidiStream
+	case clientStream:
+		return grpcTypeClientStream
+	case serverStream:
+		return grpcTypeServerStream
+	default:
+		return grpcTypeUnary
+	}
+}
+
+func metricsInterceptor(m *grpcMetrics) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req interface{},
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
-		// update handler count metric
-		requests.WithLabelValues(info.FullMethod).Inc()
+		done := m.serverStartedRPC(grpcTypeUnary, info.FullMethod)
 
-		start := time.Now()
 		resp, err := handler(ctx, req)
+		done(err)
+
+		return resp, err
+	}
+}
+
+func streamMetricsInterceptor(m *grpcMetrics) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		rpcType := grpcType(info.IsClientStream, info.IsServerStream)
+		done := m.serverStartedRPC(rpcType, info.FullMethod)
+
+		err := handler(srv, &monitoredServerStream{
+			ServerStream: stream,
+			metrics:      m,
+			rpcType:      rpcType,
+			fullMethod:   info.FullMethod,
+		})
+		done(err)
+
+		return err
+	}
+}
+
+// serverStartedRPC records the started RPC, done must be called with its result.
+func (m *grpcMetrics) serverStartedRPC(rpcType, fullMethod string) (done func(err error)) {
+	service, method := splitFullMethod(fullMethod)
+
+	m.serverStarted.WithLabelValues(rpcType, service, method).Inc()
+	inFlight := m.serverInFlight.WithLabelValues(rpcType, service, method)
+	inFlight.Inc()
+
+	if m.legacy != nil {
+		m.legacy.requests.WithLabelValues(fullMethod).Inc()
+	}
+
+	start := time.Now()
+
+	return func(err error) {
+		duration := time.Since(start)
+		code := status.Code(err)
+
+		inFlight.Dec()
+		m.serverHandled.WithLabelValues(rpcType, service, method, code.String()).Inc()
+		m.serverHandlingSeconds.WithLabelValues(rpcType, service, method).Observe(duration.Seconds())
+
+		if m.legacy != nil {
+			m.legacy.serverHandled(rpcType, fullMethod, duration, err)
+		}
+	}
+}
+
+func (m *grpcMetrics) serverPanicRecovered(fullMethod string) {
+	service, method := splitFullMethod(fullMethod)
+	m.serverPanics.WithLabelValues(service, method).Inc()
+
+	if m.legacy != nil {
+		m.legacy.panics.WithLabelValues(fullMethod).Inc()
+	}
+}
 
-		responseTime.WithLabelValues(info.FullMethod).Observe(float64(time.Since(start).Milliseconds()))
+func (m *grpcMetrics) serverRateLimitedRPC(fullMethod, limiter string) {
+	service, method := splitFullMethod(fullMethod)
+	m.serverRateLimited.WithLabelValues(service, method, limiter).Inc()
 
-		if err != nil {
-			st, ok := status.FromError(err)
-			if !ok {
-				return resp, err
+	if m.legacy != nil {
+		m.legacy.rateLimited.WithLabelValues(fullMethod, limiter).Inc()
+	}
+}
+
+// monitoredServerStream counts messages passed through the stream.
+type monitoredServerStream struct {
+	grpc.ServerStream
+	metrics    *grpcMetrics
+	rpcType    string
+	fullMethod string
+}
+
+func (s *monitoredServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		service, method := splitFullMethod(s.fullMethod)
+		current := s.metrics
+		current.serverMsgSent.WithLabelValues(s.rpcType, service, method).Inc()
+
+		if current.legacy != nil {
+			current.legacy.streamMessagesSent.WithLabelValues(s.fullMethod).Inc()
+		}
+	}
+
+	return err
+}
+
+func (s *monitoredServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		service, method := splitFullMethod(s.fullMethod)
+		current := s.metrics
+		current.serverMsgReceived.WithLabelValues(s.rpcType, service, method).Inc()
+
+		if current.legacy != nil {
+			current.legacy.streamMessagesReceived.WithLabelValues(s.fullMethod).Inc()
+		}
+	}
+
+	return err
+}
+
+// clientStartedRPC records the started RPC, done must be called with its result.
+func (m *grpcMetrics) clientStartedRPC(target, rpcType, fullMethod string) (done func(err error)) {
+	service, method := splitFullMethod(fullMethod)
+
+	m.clientStarted.WithLabelValues(target, rpcType, service, method).Inc()
+	inFlight := m.clientInFlight.WithLabelValues(target, rpcType, service, method)
+	inFlight.Inc()
+
+	start := time.Now()
+
+	return func(err error) {
+		duration := time.Since(start)
+		code := status.Code(err)
+
+		inFlight.Dec()
+		m.clientHandled.WithLabelValues(target, rpcType, service, method, code.String()).Inc()
+		m.clientHandlingSeconds.WithLabelValues(target, rpcType, service, method).Observe(duration.Seconds())
+
+		if m.legacy != nil {
+			m.legacy.clientRequests.WithLabelValues(target, fullMethod, code.String()).Inc()
+			if rpcType == grpcTypeUnary {
+				m.legacy.clientResponseTime.WithLabelValues(target, fullMethod).Observe(duration.Seconds())
 			}
+		}
+	}
+}
+
+func (m *grpcMetrics) clientRejectedRPC(target, fullMethod, reason string) {
+	service, method := splitFullMethod(fullMethod)
+	m.clientRejected.WithLabelValues(target, service, method, reason).Inc()
+
+	if m.legacy != nil {
+		m.legacy.clientRejected.WithLabelValues(target, fullMethod, reason).Inc()
+	}
+}
+
+// clientCircuitBreakerChanged records the breaker state, transition is false for a new breaker.
+func (m *grpcMetrics) clientCircuitBreakerChanged(target, fullMethod string, state circuitBreakerState, transition bool) {
+	service, method := splitFullMethod(fullMethod)
+	m.clientCircuitBreakerState.WithLabelValues(target, service, method).Set(float64(state))
+
+	if transition {
+		m.clientCircuitBreakerTransitions.WithLabelValues(target, service, method, state.String()).Inc()
+	}
 
-			statusCodes.WithLabelValues(info.FullMethod, st.Code().String()).Inc()
+	if m.legacy != nil {
+		m.legacy.clientCircuitBreakerState.WithLabelValues(target, fullMethod).Set(float64(state))
+		if transition {
+			m.legacy.clientCircuitBreakerTransitions.WithLabelValues(target, fullMethod, state.String()).Inc()
 		}
+	}
+}
 
-		return resp, err
+// monitoredClientStream counts messages and records the RPC result when the stream is finished.
+type monitoredClientStream struct {
+	grpc.ClientStream
+	desc       *grpc.StreamDesc
+	target     string
+	rpcType    string
+	fullMethod string
+
+	once sync.Once
+	done func(err error)
+}
+
+func (s *monitoredClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		service, method := splitFullMethod(s.fullMethod)
+		metrics.Load().clientMsgSent.WithLabelValues(s.target, s.rpcType, service, method).Inc()
+	}
+
+	return err
+}
+
+func (s *monitoredClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+
+	switch {
+	case err == nil:
+		service, method := splitFullMethod(s.fullMethod)
+		metrics.Load().clientMsgReceived.WithLabelValues(s.target, s.rpcType, service, method).Inc()
+
+		// the only response of client streaming RPC finishes it
+		if !s.desc.ServerStreams {
+			s.finish(nil)
+		}
+	case errors.Is(err, io.EOF):
+		s.finish(nil)
+	default:
+		s.finish(err)
 	}
+
+	return err
+}
+
+func (s *monitoredClientStream) finish(err error) {
+	s.once.Do(func() {
+		s.done(err)
+	})
 }
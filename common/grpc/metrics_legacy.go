@@ -0,0 +1,169 @@
+package grpc
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/status"
+)
+
+// legacyMetrics are named by the app name, they are exported with MetricsConfig.LegacyNames
+// until dashboards and alerts are moved to the new names.
+type legacyMetrics struct {
+	requests                        *prometheus.CounterVec
+	responseTime                    *prometheus.HistogramVec
+	statusCodes                     *prometheus.CounterVec
+	panics                          *prometheus.CounterVec
+	rateLimited                     *prometheus.CounterVec
+	concurrentRequests              prometheus.Gauge
+	clientRequests                  *prometheus.CounterVec
+	clientResponseTime              *prometheus.HistogramVec
+	clientRejected                  *prometheus.CounterVec
+	clientCircuitBreakerState       *prometheus.GaugeVec
+	clientCircuitBreakerTransitions *prometheus.CounterVec
+	streamMessagesReceived          *prometheus.CounterVec
+	streamMessagesSent              *prometheus.CounterVec
+	streamDuration                  *prometheus.HistogramVec
+}
+
+func newLegacyMetrics() *legacyMetrics {
+	prefix := strings.ReplaceAll(os.Getenv("APP_NAME"), "-", "_")
+
+	return &legacyMetrics{
+		requests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: fmt.Sprintf("%s_grpc_requests", prefix),
+				Help: "Count of grpc requests by handlers",
+			},
+			[]string{"handler"},
+		),
+		responseTime: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    fmt.Sprintf("%s_grpc_response_time", prefix),
+				Help:    "Response time of grpc requests",
+				Buckets: []float64{0.1, 0.5, 1, 2, 5},
+			},
+			[]string{"handler"},
+		),
+		statusCodes: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: fmt.Sprintf("%s_status_codes", prefix),
+				Help: "Handlers not successful status codes",
+			},
+			[]string{"handler", "status_code"},
+		),
+		panics: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: fmt.Sprintf("%s_grpc_panics", prefix),
+				Help: "Count of panics recovered in handlers",
+			},
+			[]string{"handler"},
+		),
+		rateLimited: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: fmt.Sprintf("%s_grpc_rate_limited", prefix),
+				Help: "Count of requests rejected by rate and concurrency limits",
+			},
+			[]string{"handler", "limiter"},
+		),
+		concurrentRequests: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: fmt.Sprintf("%s_grpc_concurrent_requests", prefix),
+				Help: "Count of requests handled concurrently, tracked when concurrency limit is set",
+			},
+		),
+		clientRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: fmt.Sprintf("%s_grpc_client_requests", prefix),
+				Help: "Count of outgoing grpc requests by target, method and status code",
+			},
+			[]string{"target", "handler", "status_code"},
+		),
+		clientResponseTime: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    fmt.Sprintf("%s_grpc_client_response_time_seconds", prefix),
+				Help:    "Response time of outgoing grpc requests in seconds",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"target", "handler"},
+		),
+		clientRejected: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: fmt.Sprintf("%s_grpc_client_rejected", prefix),
+				Help: "Count of outgoing grpc requests rejected by circuit breaker or bulkhead",
+			},
+			[]string{"target", "handler", "reason"},
+		),
+		clientCircuitBreakerState: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: fmt.Sprintf("%s_grpc_client_circuit_breaker_state", prefix),
+				Help: "Circuit breaker state: 0 - closed, 1 - half-open, 2 - open",
+			},
+			[]string{"target", "handler"},
+		),
+		clientCircuitBreakerTransitions: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: fmt.Sprintf("%s_grpc_client_circuit_breaker_transitions", prefix),
+				Help: "Count of circuit breaker transitions by the new state",
+			},
+			[]string{"target", "handler", "state"},
+		),
+		streamMessagesReceived: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: fmt.Sprintf("%s_grpc_stream_messages_received", prefix),
+				Help: "Count of messages received from clients by stream handlers",
+			},
+			[]string{"handler"},
+		),
+		streamMessagesSent: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: fmt.Sprintf("%s_grpc_stream_messages_sent", prefix),
+				Help: "Count of messages sent to clients by stream handlers",
+			},
+			[]string{"handler"},
+		),
+		streamDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    fmt.Sprintf("%s_grpc_stream_duration_seconds", prefix),
+				Help:    "Duration of grpc streams in seconds",
+				Buckets: []float64{0.1, 1, 10, 60, 300, 1800, 3600},
+			},
+			[]string{"handler"},
+		),
+	}
+}
+
+func (m *legacyMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.requests,
+		m.responseTime,
+		m.statusCodes,
+		m.panics,
+		m.rateLimited,
+		m.concurrentRequests,
+		m.clientRequests,
+		m.clientResponseTime,
+		m.clientRejected,
+		m.clientCircuitBreakerState,
+		m.clientCircuitBreakerTransitions,
+		m.streamMessagesReceived,
+		m.streamMessagesSent,
+		m.streamDuration,
+	}
+}
+
+// serverHandled keeps the old semantics: unary response time is observed in milliseconds.
+func (m *legacyMetrics) serverHandled(rpcType, fullMethod string, duration time.Duration, err error) {
+	if rpcType == grpcTypeUnary {
+		m.responseTime.WithLabelValues(fullMethod).Observe(float64(duration.Milliseconds()))
+	} else {
+		m.streamDuration.WithLabelValues(fullMethod).Observe(duration.Seconds())
+	}
+
+	if st, ok := status.FromError(err); ok && err != nil {
+		m.statusCodes.WithLabelValues(fullMethod, st.Code().String()).Inc()
+	}
+}
@@ -0,0 +1,43 @@
+package grpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestServer_Registry(t *testing.T) {
+	first := &Server{}
+	first.initMetrics()
+
+	second := &Server{}
+	second.initMetrics()
+
+	scrape := func(s *Server) string {
+		mux := http.NewServeMux()
+		s.registerMetricsHandlers(mux)
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		return w.Body.String()
+	}
+
+	_, err := metricsInterceptor(first.metrics)(
+		context.Background(),
+		nil,
+		&grpc.UnaryServerInfo{FullMethod: testMethod},
+		func(context.Context, interface{}) (interface{}, error) { return nil, nil },
+	)
+	require.NoError(t, err)
+
+	require.Contains(t, scrape(first), `grpc_server_handled_total{`)
+	require.NotContains(t, scrape(second), `grpc_server_handled_total{`)
+
+	require.Same(t, second.Registry(), GetRegistry())
+}
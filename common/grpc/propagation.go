@@ -0,0 +1,109 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	legacyTraceIDKey = "x-trace-id"
+	legacySpanIDKey  = "x-span-id"
+)
+
+// propagator implements W3C Trace Context (traceparent, tracestate) and Baggage.
+var propagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+// propagationHeaders are forwarded from the gateway to grpc metadata.
+var propagationHeaders = []string{"traceparent", "tracestate", "baggage"}
+
+// metadataCarrier adapts grpc metadata to propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// extractTraceContext puts the remote span and baggage from metadata to ctx.
+// Services not migrated to W3C Trace Context send legacy x-trace-id and x-span-id.
+func extractTraceContext(ctx context.Context, md metadata.MD) context.Context {
+	ctx = propagator.Extract(ctx, metadataCarrier(md))
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+
+	if parentSpanCtx, ok := legacySpanContext(md); ok {
+		return trace.ContextWithRemoteSpanContext(ctx, parentSpanCtx)
+	}
+
+	return ctx
+}
+
+// injectOutgoingTraceContext replaces trace context in outgoing metadata with the current span.
+func injectOutgoingTraceContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		md = metadata.New(nil)
+	} else {
+		md = md.Copy()
+	}
+
+	propagator.Inject(ctx, metadataCarrier(md))
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// legacySpanContext reads parent span from x-trace-id and x-span-id metadata.
+// Jaeger ids may be shorter than W3C ones, so they are padded with zeros.
+func legacySpanContext(md metadata.MD) (trace.SpanContext, bool) {
+	if len(md.Get(legacyTraceIDKey)) == 0 || len(md.Get(legacySpanIDKey)) == 0 {
+		return trace.SpanContext{}, false
+	}
+
+	traceID, err := trace.TraceIDFromHex(fmt.Sprintf("%032s", md.Get(legacyTraceIDKey)[0]))
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+
+	legacySpanID := md.Get(legacySpanIDKey)[0]
+	// root spans of old versions sent the trace id as the span id, its low half is used
+	if len(legacySpanID) == 32 {
+		legacySpanID = legacySpanID[16:]
+	}
+
+	spanID, err := trace.SpanIDFromHex(fmt.Sprintf("%016s", legacySpanID))
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}), true
+}
@@ -0,0 +1,148 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestLegacySpanContext(t *testing.T) {
+	tests := []struct {
+		name        string
+		md          metadata.MD
+		wantOK      bool
+		wantTraceID string
+		wantSpanID  string
+	}{
+		{
+			name:        "valid ids",
+			md:          metadata.Pairs(legacyTraceIDKey, "4bf92f3577b34da6a3ce929d0e0e4736", legacySpanIDKey, "00f067aa0ba902b7"),
+			wantOK:      true,
+			wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantSpanID:  "00f067aa0ba902b7",
+		},
+		{
+			name:        "short ids are padded with zeros",
+			md:          metadata.Pairs(legacyTraceIDKey, "a3ce929d0e0e4736", legacySpanIDKey, "ba902b7"),
+			wantOK:      true,
+			wantTraceID: "0000000000000000a3ce929d0e0e4736",
+			wantSpanID:  "000000000ba902b7",
+		},
+		{
+			name:        "trace id sent as span id is truncated to its low half",
+			md:          metadata.Pairs(legacyTraceIDKey, "4bf92f3577b34da6a3ce929d0e0e4736", legacySpanIDKey, "4bf92f3577b34da6a3ce929d0e0e4736"),
+			wantOK:      true,
+			wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantSpanID:  "a3ce929d0e0e4736",
+		},
+		{
+			name: "too long trace id",
+			md:   metadata.Pairs(legacyTraceIDKey, "14bf92f3577b34da6a3ce929d0e0e4736", legacySpanIDKey, "00f067aa0ba902b7"),
+		},
+		{
+			name: "too long span id",
+			md:   metadata.Pairs(legacyTraceIDKey, "4bf92f3577b34da6a3ce929d0e0e4736", legacySpanIDKey, "100f067aa0ba902b7"),
+		},
+		{
+			name: "non-hex trace id",
+			md:   metadata.Pairs(legacyTraceIDKey, "not-a-trace-id", legacySpanIDKey, "00f067aa0ba902b7"),
+		},
+		{
+			name: "non-hex span id",
+			md:   metadata.Pairs(legacyTraceIDKey, "4bf92f3577b34da6a3ce929d0e0e4736", legacySpanIDKey, "not-a-span-id"),
+		},
+		{
+			name: "zero trace id",
+			md:   metadata.Pairs(legacyTraceIDKey, "0", legacySpanIDKey, "00f067aa0ba902b7"),
+		},
+		{
+			name: "missing span id",
+			md:   metadata.Pairs(legacyTraceIDKey, "4bf92f3577b34da6a3ce929d0e0e4736"),
+		},
+		{
+			name: "no legacy headers",
+			md:   metadata.MD{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spanCtx, ok := legacySpanContext(tt.md)
+			require.Equal(t, tt.wantOK, ok)
+			if !ok {
+				return
+			}
+
+			require.Equal(t, tt.wantTraceID, spanCtx.TraceID().String())
+			require.Equal(t, tt.wantSpanID, spanCtx.SpanID().String())
+			require.True(t, spanCtx.IsSampled())
+			require.True(t, spanCtx.IsRemote())
+		})
+	}
+}
+
+func TestExtractTraceContext(t *testing.T) {
+	const (
+		traceparent   = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+		legacyTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		legacySpanID  = "00f067aa0ba902b7"
+	)
+
+	tests := []struct {
+		name        string
+		md          metadata.MD
+		wantValid   bool
+		wantTraceID string
+		wantSpanID  string
+	}{
+		{
+			name:        "traceparent",
+			md:          metadata.Pairs("traceparent", traceparent),
+			wantValid:   true,
+			wantTraceID: "0af7651916cd43dd8448eb211c80319c",
+			wantSpanID:  "b7ad6b7169203331",
+		},
+		{
+			name:        "traceparent takes precedence over legacy headers",
+			md:          metadata.Pairs("traceparent", traceparent, legacyTraceIDKey, legacyTraceID, legacySpanIDKey, legacySpanID),
+			wantValid:   true,
+			wantTraceID: "0af7651916cd43dd8448eb211c80319c",
+			wantSpanID:  "b7ad6b7169203331",
+		},
+		{
+			name:        "legacy headers",
+			md:          metadata.Pairs(legacyTraceIDKey, legacyTraceID, legacySpanIDKey, legacySpanID),
+			wantValid:   true,
+			wantTraceID: legacyTraceID,
+			wantSpanID:  legacySpanID,
+		},
+		{
+			name:        "legacy headers are used if traceparent is invalid",
+			md:          metadata.Pairs("traceparent", "invalid", legacyTraceIDKey, legacyTraceID, legacySpanIDKey, legacySpanID),
+			wantValid:   true,
+			wantTraceID: legacyTraceID,
+			wantSpanID:  legacySpanID,
+		},
+		{
+			name: "no trace context",
+			md:   metadata.MD{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spanCtx := trace.SpanContextFromContext(extractTraceContext(context.Background(), tt.md))
+			require.Equal(t, tt.wantValid, spanCtx.IsValid())
+			if !tt.wantValid {
+				return
+			}
+
+			require.Equal(t, tt.wantTraceID, spanCtx.TraceID().String())
+			require.Equal(t, tt.wantSpanID, spanCtx.SpanID().String())
+			require.True(t, spanCtx.IsRemote())
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package grpc
+
+import (
+	"net/http"
+	"net/textproto"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+func (s *Server) newProxyRouter() *runtime.ServeMux {
+	options := append([]runtime.ServeMuxOption{
+		runtime.WithIncomingHeaderMatcher(s.proxyHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(proxyOutgoingHeaderMatcher),
+		runtime.WithErrorHandler(GatewayErrorHandler),
+		runtime.WithRoutingErrorHandler(GatewayRoutingErrorHandler),
+	}, s.gatewayOptions...)
+
+	return runtime.NewServeMux(options...)
+}
+
+// proxyHeaderMatcher forwards headers required by server options to grpc metadata as is,
+// the rest is handled by runtime.DefaultHeaderMatcher.
+func (s *Server) proxyHeaderMatcher(key string) (string, bool) {
+	for _, header := range s.proxyHeaders {
+		if textproto.CanonicalMIMEHeaderKey(header) == textproto.CanonicalMIMEHeaderKey(key) {
+			return header, true
+		}
+	}
+
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+func (s *Server) proxyHandler() http.Handler {
+	if s.cors == nil {
+		return s.proxyRouter
+	}
+
+	return s.cors.handler(s.proxyRouter)
+}
+
+// proxyOutgoingHeaderMatcher returns request id in X-Request-Id header,
+// the rest of grpc headers are prefixed with Grpc-Metadata- as by default.
+func proxyOutgoingHeaderMatcher(key string) (string, bool) {
+	if key == requestIDHeader {
+		return textproto.CanonicalMIMEHeaderKey(requestIDHeader), true
+	}
+
+	return runtime.MetadataHeaderPrefix + key, true
+}
@@ -0,0 +1,370 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fidesy/sdk/common/grpc/auth"
+	"github.com/fidesy/sdk/common/grpc/config"
+	"github.com/fidesy/sdk/common/logger"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	defaultRateLimitRefreshInterval = 10 * time.Second
+	// limiters of callers without requests for this time are removed
+	rateLimiterIdleTimeout = 10 * time.Minute
+)
+
+type (
+	RateLimit struct {
+		// Requests per second, 0 disables the limit
+		RPS float64
+		// Default is RPS rounded up
+		Burst int
+	}
+
+	RateLimitConfig struct {
+		// Limit for every method and caller
+		Default RateLimit
+		// Overrides by full method name or service wildcard ("/users_service.UserService/*")
+		Methods map[string]RateLimit
+
+		// Metadata key identifying the caller, when it's missing the caller is identified
+		// by client certificate, authenticated principal or peer address.
+		CallerHeader string
+
+		// Max concurrent requests to the server, 0 disables the limit
+		MaxInFlight int64
+
+		// realtime-configs-service keys to tune Default and MaxInFlight live, WithRealtimeConfigsService is required.
+		RPSConfigKey         string
+		BurstConfigKey       string
+		MaxInFlightConfigKey string
+		// Default 10s
+		RefreshInterval time.Duration
+	}
+
+	rateLimiter struct {
+		config RateLimitConfig
+
+		mu       sync.Mutex
+		limit    RateLimit
+		limiters map[string]*callerLimiter
+
+		maxInFlight atomic.Int64
+		inFlight    atomic.Int64
+
+		// metrics of the server, set by NewServer
+		metrics *grpcMetrics
+	}
+
+	callerLimiter struct {
+		limiter  *rate.Limiter
+		override bool
+		lastSeen time.Time
+	}
+)
+
+// WithRateLimit limits requests rate by method and caller and the number of concurrent requests.
+// Rejected calls get codes.ResourceExhausted with retry-after metadata and RetryInfo details.
+func WithRateLimit(config RateLimitConfig) ServerOption {
+	return func(s *Server) error {
+		if config.RefreshInterval == 0 {
+			config.RefreshInterval = defaultRateLimitRefreshInterval
+		}
+
+		limiter := &rateLimiter{
+			config:   config,
+			limit:    config.Default,
+			limiters: make(map[string]*callerLimiter),
+		}
+		limiter.maxInFlight.Store(config.MaxInFlight)
+
+		s.rateLimiter = limiter
+		return nil
+	}
+}
+
+func rateLimitInterceptor(limiter *rateLimiter) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		release, err := limiter.acquire(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		return handler(ctx, req)
+	}
+}
+
+func streamRateLimitInterceptor(limiter *rateLimiter) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		release, err := limiter.acquire(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		return handler(srv, stream)
+	}
+}
+
+// acquire checks both limits, release must be called when the request is finished.
+func (l *rateLimiter) acquire(ctx context.Context, fullMethod string) (func(), error) {
+	if delay, ok := l.reserve(fullMethod, l.caller(ctx)); !ok {
+		l.metrics.serverRateLimitedRPC(fullMethod, "rate")
+		return nil, resourceExhausted(ctx, "rate limit exceeded", delay)
+	}
+
+	inFlight := l.inFlight.Add(1)
+	release := func() {
+		l.setLegacyConcurrentRequests(l.inFlight.Add(-1))
+	}
+
+	if maxInFlight := l.maxInFlight.Load(); maxInFlight > 0 && inFlight > maxInFlight {
+		release()
+		l.metrics.serverRateLimitedRPC(fullMethod, "concurrency")
+		return nil, resourceExhausted(ctx, "concurrency limit exceeded", time.Second)
+	}
+
+	l.setLegacyConcurrentRequests(inFlight)
+
+	return release, nil
+}
+
+// setLegacyConcurrentRequests updates the legacy gauge, grpc_server_in_flight_requests replaces it.
+func (l *rateLimiter) setLegacyConcurrentRequests(inFlight int64) {
+	if legacy := l.metrics.legacy; legacy != nil {
+		legacy.concurrentRequests.Set(float64(inFlight))
+	}
+}
+
+// reserve takes a token from the bucket of method and caller, when it's empty returns time until the next token.
+func (l *rateLimiter) reserve(fullMethod, caller string) (time.Duration, bool) {
+	limit, override := l.methodLimit(fullMethod)
+
+	l.mu.Lock()
+	if !override {
+		limit = l.limit
+	}
+
+	if limit.RPS <= 0 {
+		l.mu.Unlock()
+		return 0, true
+	}
+
+	key := fullMethod + " " + caller
+	cl, ok := l.limiters[key]
+	if !ok {
+		cl = &callerLimiter{
+			limiter:  rate.NewLimiter(rate.Limit(limit.RPS), burst(limit)),
+			override: override,
+		}
+		l.limiters[key] = cl
+	}
+	cl.lastSeen = time.Now()
+	l.mu.Unlock()
+
+	reservation := cl.limiter.Reserve()
+	delay := reservation.Delay()
+	if delay == 0 {
+		return 0, true
+	}
+
+	reservation.Cancel()
+
+	return delay, false
+}
+
+func (l *rateLimiter) methodLimit(fullMethod string) (RateLimit, bool) {
+	if limit, ok := l.config.Methods[fullMethod]; ok {
+		return limit, true
+	}
+
+	if i := strings.LastIndex(fullMethod, "/"); i > 0 {
+		if limit, ok := l.config.Methods[fullMethod[:i]+"/*"]; ok {
+			return limit, true
+		}
+	}
+
+	return RateLimit{}, false
+}
+
+func (l *rateLimiter) caller(ctx context.Context) string {
+	if l.config.CallerHeader != "" {
+		if values := metadata.ValueFromIncomingContext(ctx, l.config.CallerHeader); len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+
+	if identity, ok := ServiceIdentityFromContext(ctx); ok {
+		return identity
+	}
+
+	if principal, ok := auth.FromContext(ctx); ok && principal.Subject != "" {
+		return principal.Subject
+	}
+
+	if address, ok := forwardedFor(ctx); ok {
+		return address
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			return p.Addr.String()
+		}
+
+		return host
+	}
+
+	return ""
+}
+
+// run refreshes limits from realtime-configs-service and removes idle limiters until ctx is done.
+func (l *rateLimiter) run(ctx context.Context) {
+	ticker := time.NewTicker(l.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		l.refreshLimits(ctx)
+		l.removeIdle()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l *rateLimiter) refreshLimits(ctx context.Context) {
+	if l.config.MaxInFlightConfigKey != "" {
+		if value := config.GetValue(ctx, l.config.MaxInFlightConfigKey).String(); value != "" {
+			maxInFlight, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				logger.Errorf("rate limit: %v", err, zap.String("config_key", l.config.MaxInFlightConfigKey))
+			} else {
+				l.maxInFlight.Store(maxInFlight)
+			}
+		}
+	}
+
+	if l.config.RPSConfigKey == "" && l.config.BurstConfigKey == "" {
+		return
+	}
+
+	limit := l.config.Default
+	if l.config.RPSConfigKey != "" {
+		if value := config.GetValue(ctx, l.config.RPSConfigKey).String(); value != "" {
+			rps, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				logger.Errorf("rate limit: %v", err, zap.String("config_key", l.config.RPSConfigKey))
+			} else {
+				limit.RPS = rps
+			}
+		}
+	}
+
+	if l.config.BurstConfigKey != "" {
+		if value := config.GetValue(ctx, l.config.BurstConfigKey).String(); value != "" {
+			burst, err := strconv.Atoi(value)
+			if err != nil {
+				logger.Errorf("rate limit: %v", err, zap.String("config_key", l.config.BurstConfigKey))
+			} else {
+				limit.Burst = burst
+			}
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if limit == l.limit {
+		return
+	}
+
+	logger.Info(fmt.Sprintf("rate limit is updated: %v rps, %d burst", limit.RPS, burst(limit)))
+
+	l.limit = limit
+	for key, cl := range l.limiters {
+		if cl.override {
+			continue
+		}
+
+		// limiter can't be disabled in place, it's recreated on the next request
+		if limit.RPS <= 0 {
+			delete(l.limiters, key)
+			continue
+		}
+
+		cl.limiter.SetLimit(rate.Limit(limit.RPS))
+		cl.limiter.SetBurst(burst(limit))
+	}
+}
+
+func (l *rateLimiter) removeIdle() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, cl := range l.limiters {
+		if time.Since(cl.lastSeen) > rateLimiterIdleTimeout {
+			delete(l.limiters, key)
+		}
+	}
+}
+
+func burst(limit RateLimit) int {
+	if limit.Burst > 0 {
+		return limit.Burst
+	}
+
+	return int(math.Ceil(limit.RPS))
+}
+
+func resourceExhausted(ctx context.Context, message string, retryAfter time.Duration) error {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	err := grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.FormatInt(seconds, 10)))
+	if err != nil {
+		logger.Errorf("grpc.SetHeader: %v", err)
+	}
+
+	st, err := status.New(codes.ResourceExhausted, message).WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(retryAfter),
+	})
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, message)
+	}
+
+	return st.Err()
+}
@@ -0,0 +1,222 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fidesy/sdk/common/grpc/auth"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func newTestRateLimiter(config RateLimitConfig) *rateLimiter {
+	s := &Server{}
+	_ = WithRateLimit(config)(s)
+	s.rateLimiter.metrics = newGRPCMetrics(MetricsConfig{})
+
+	return s.rateLimiter
+}
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	type request struct {
+		method string
+		caller string
+		want   bool
+	}
+
+	tests := []struct {
+		name     string
+		config   RateLimitConfig
+		requests []request
+	}{
+		{
+			name:   "burst",
+			config: RateLimitConfig{Default: RateLimit{RPS: 1, Burst: 2}},
+			requests: []request{
+				{method: testMethod, caller: "a", want: true},
+				{method: testMethod, caller: "a", want: true},
+				{method: testMethod, caller: "a", want: false},
+			},
+		},
+		{
+			name:   "burst is rps rounded up by default",
+			config: RateLimitConfig{Default: RateLimit{RPS: 1.5}},
+			requests: []request{
+				{method: testMethod, caller: "a", want: true},
+				{method: testMethod, caller: "a", want: true},
+				{method: testMethod, caller: "a", want: false},
+			},
+		},
+		{
+			name:   "callers are limited separately",
+			config: RateLimitConfig{Default: RateLimit{RPS: 1}},
+			requests: []request{
+				{method: testMethod, caller: "a", want: true},
+				{method: testMethod, caller: "a", want: false},
+				{method: testMethod, caller: "b", want: true},
+			},
+		},
+		{
+			name:   "methods are limited separately",
+			config: RateLimitConfig{Default: RateLimit{RPS: 1}},
+			requests: []request{
+				{method: testMethod, caller: "a", want: true},
+				{method: testMethod, caller: "a", want: false},
+				{method: "/users.UserService/ListUsers", caller: "a", want: true},
+			},
+		},
+		{
+			name:   "no limit",
+			config: RateLimitConfig{},
+			requests: []request{
+				{method: testMethod, caller: "a", want: true},
+				{method: testMethod, caller: "a", want: true},
+				{method: testMethod, caller: "a", want: true},
+			},
+		},
+		{
+			name: "method override",
+			config: RateLimitConfig{
+				Default: RateLimit{RPS: 1},
+				Methods: map[string]RateLimit{testMethod: {RPS: 2}},
+			},
+			requests: []request{
+				{method: testMethod, caller: "a", want: true},
+				{method: testMethod, caller: "a", want: true},
+				{method: testMethod, caller: "a", want: false},
+			},
+		},
+		{
+			name: "method without limit",
+			config: RateLimitConfig{
+				Default: RateLimit{RPS: 1},
+				Methods: map[string]RateLimit{testMethod: {}},
+			},
+			requests: []request{
+				{method: testMethod, caller: "a", want: true},
+				{method: testMethod, caller: "a", want: true},
+			},
+		},
+		{
+			name: "service wildcard",
+			config: RateLimitConfig{
+				Default: RateLimit{RPS: 10},
+				Methods: map[string]RateLimit{"/users.UserService/*": {RPS: 1}},
+			},
+			requests: []request{
+				{method: testMethod, caller: "a", want: true},
+				{method: testMethod, caller: "a", want: false},
+				{method: "/orders.OrderService/GetOrder", caller: "a", want: true},
+				{method: "/orders.OrderService/GetOrder", caller: "a", want: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newTestRateLimiter(tt.config)
+
+			for i, r := range tt.requests {
+				delay, ok := limiter.reserve(r.method, r.caller)
+				require.Equal(t, r.want, ok, "request %d", i)
+
+				if ok {
+					require.Zero(t, delay)
+				} else {
+					require.Greater(t, delay, time.Duration(0))
+					require.LessOrEqual(t, delay, time.Second)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiter_Caller(t *testing.T) {
+	tcpPeer := &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 50000}}
+	gatewayPeer := &peer.Peer{Addr: gatewayAddr{}}
+
+	tests := []struct {
+		name         string
+		callerHeader string
+		peer         *peer.Peer
+		md           metadata.MD
+		principal    *auth.Principal
+		want         string
+	}{
+		{
+			name: "peer address",
+			peer: tcpPeer,
+			want: "10.0.0.1",
+		},
+		{
+			name: "forwarded address of gateway requests",
+			peer: gatewayPeer,
+			md:   metadata.Pairs("x-forwarded-for", "10.0.0.2"),
+			want: "10.0.0.2",
+		},
+		{
+			name: "forwarded address sent by the client isn't trusted",
+			peer: gatewayPeer,
+			md:   metadata.Pairs("x-forwarded-for", "192.168.0.1, 10.0.0.2"),
+			want: "10.0.0.2",
+		},
+		{
+			name: "forwarded address of direct requests isn't trusted",
+			peer: tcpPeer,
+			md:   metadata.Pairs("x-forwarded-for", "192.168.0.1"),
+			want: "10.0.0.1",
+		},
+		{
+			name:      "principal",
+			peer:      tcpPeer,
+			principal: &auth.Principal{Subject: "user-1"},
+			want:      "user-1",
+		},
+		{
+			name:         "caller header",
+			callerHeader: "x-caller",
+			peer:         gatewayPeer,
+			md:           metadata.Pairs("x-caller", "orders-service", "x-forwarded-for", "192.168.0.1"),
+			principal:    &auth.Principal{Subject: "user-1"},
+			want:         "orders-service",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newTestRateLimiter(RateLimitConfig{CallerHeader: tt.callerHeader})
+
+			ctx := peer.NewContext(context.Background(), tt.peer)
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+			if tt.principal != nil {
+				ctx = auth.NewContext(ctx, tt.principal)
+			}
+
+			require.Equal(t, tt.want, limiter.caller(ctx))
+		})
+	}
+}
+
+func TestRateLimiter_MaxInFlight(t *testing.T) {
+	limiter := newTestRateLimiter(RateLimitConfig{MaxInFlight: 1})
+	ctx := context.Background()
+
+	release, err := limiter.acquire(ctx, testMethod)
+	require.NoError(t, err)
+
+	_, err = limiter.acquire(ctx, testMethod)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	release()
+
+	release, err = limiter.acquire(ctx, testMethod)
+	require.NoError(t, err)
+	release()
+}
@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/fidesy/sdk/common/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RecoveryHandler converts a recovered panic to the error returned to the client.
+type RecoveryHandler func(ctx context.Context, p interface{}) error
+
+// WithoutRecovery disables the default panic recovery interceptors.
+func WithoutRecovery() ServerOption {
+	return func(s *Server) error {
+		s.disableRecovery = true
+		return nil
+	}
+}
+
+// WithRecoveryHandler sets a custom handler for recovered panics, by default codes.Internal is returned.
+func WithRecoveryHandler(handler RecoveryHandler) ServerOption {
+	return func(s *Server) error {
+		if handler == nil {
+			return errors.New("recovery handler is nil, use WithoutRecovery to disable recovery")
+		}
+
+		s.recoveryHandler = handler
+		return nil
+	}
+}
+
+func defaultRecoveryHandler(_ context.Context, _ interface{}) error {
+	return status.Error(codes.Internal, "internal error")
+}
+
+func recoveryInterceptor(recoveryHandler RecoveryHandler, m *grpcMetrics) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (_ interface{}, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = handlePanic(ctx, info.FullMethod, p, recoveryHandler, m)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+func streamRecoveryInterceptor(recoveryHandler RecoveryHandler, m *grpcMetrics) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = handlePanic(stream.Context(), info.FullMethod, p, recoveryHandler, m)
+			}
+		}()
+
+		return handler(srv, stream)
+	}
+}
+
+func handlePanic(ctx context.Context, fullMethod string, p interface{}, recoveryHandler RecoveryHandler, m *grpcMetrics) error {
+	m.serverPanicRecovered(fullMethod)
+
+	logger.Errorf(
+		"panic recovered: %w", fmt.Errorf("%v", p),
+		zap.String("handler", fullMethod),
+		zap.String("trace_id", traceIDFromContext(ctx)),
+		zap.ByteString("stack", debug.Stack()),
+	)
+
+	return recoveryHandler(ctx, p)
+}
@@ -0,0 +1,163 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+
+	domain_name_service "github.com/fidesy/sdk/common/grpc/pkg/domain-name-service"
+	"github.com/fidesy/sdk/common/logger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// registryTTL is the time the instance stays registered without a heartbeat, heartbeats are sent by watchHealth.
+const registryTTL = 3 * healthCheckInterval
+
+// InstanceConfig describes the instance registered in domain-name-service.
+type InstanceConfig struct {
+	// Address other services connect to, it must be unique for every replica.
+	// Default POD_IP env or hostname with the server port
+	Address string
+	// Share of requests for the weighted balancing. Default 1
+	Weight uint32
+	// Default ZONE env
+	Zone string
+	// Default Version
+	Version string
+}
+
+// WithInstance sets attributes of the instance registered in domain-name-service, WithDomainNameService is required.
+func WithInstance(config InstanceConfig) ServerOption {
+	return func(s *Server) error {
+		s.instance = config
+		return nil
+	}
+}
+
+func (s *Server) instanceDescription() *domain_name_service.Instance {
+	instance := &domain_name_service.Instance{
+		Address: s.instance.Address,
+		Weight:  s.instance.Weight,
+		Zone:    s.instance.Zone,
+		Version: s.instance.Version,
+	}
+
+	if instance.Weight == 0 {
+		instance.Weight = 1
+	}
+
+	if instance.Zone == "" {
+		instance.Zone = os.Getenv("ZONE")
+	}
+
+	if instance.Version == "" {
+		instance.Version = Version
+	}
+
+	return instance
+}
+
+// resolveInstanceAddress sets the default address of the instance, replicas of a service
+// must not share it, otherwise they overwrite and deregister each other.
+func (s *Server) resolveInstanceAddress() error {
+	if s.instance.Address != "" {
+		return nil
+	}
+
+	host := os.Getenv("POD_IP")
+	if host == "" {
+		hostname, err := os.Hostname()
+		if err != nil || hostname == "" {
+			return fmt.Errorf("instance address is unknown, set POD_IP env or WithInstance: %v", err)
+		}
+
+		host = hostname
+	}
+
+	s.instance.Address = net.JoinHostPort(host, s.port)
+
+	return nil
+}
+
+// register adds the instance to domain-name-service or refreshes its ttl.
+func (s *Server) register(ctx context.Context) error {
+	if domainNameServiceClient == nil {
+		return nil
+	}
+
+	instance := s.instanceDescription()
+
+	_, err := domainNameServiceClient.RegisterInstance(ctx, &domain_name_service.RegisterInstanceRequest{
+		ServiceName: appName,
+		Instance:    instance,
+		TtlSeconds:  uint32(registryTTL.Seconds()),
+	})
+	// domain-name-service keeps only one address per service, it is shared by replicas,
+	// so the service address balancing between them is registered once instead of the instance one
+	if status.Code(err) == codes.Unimplemented {
+		if s.legacyAddressRegistered.Load() {
+			return nil
+		}
+
+		_, err = domainNameServiceClient.UpdateAddress(ctx, &domain_name_service.UpdateAddressRequest{
+			ServiceName: appName,
+			Address:     fmt.Sprintf("%s:%s", appName, s.port),
+		})
+		if err != nil {
+			return fmt.Errorf("domainNameServiceClient.UpdateAddress: %w", err)
+		}
+
+		s.legacyAddressRegistered.Store(true)
+
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("domainNameServiceClient.RegisterInstance: %w", err)
+	}
+
+	return nil
+}
+
+// deregister removes the instance so that new connections are not routed to it.
+func (s *Server) deregister(ctx context.Context) error {
+	if domainNameServiceClient == nil {
+		return nil
+	}
+
+	_, err := domainNameServiceClient.DeregisterInstance(ctx, &domain_name_service.DeregisterInstanceRequest{
+		ServiceName: appName,
+		Address:     s.instanceDescription().Address,
+	})
+	// domain-name-service keeps one address shared by replicas and can't clear it only if it is still ours,
+	// other replicas may be serving, so it is kept
+	if status.Code(err) == codes.Unimplemented {
+		logger.Info("domain-name-service doesn't support instances, the address is kept registered")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("domainNameServiceClient.DeregisterInstance: %w", err)
+	}
+
+	return nil
+}
+
+// heartbeat keeps the instance registered while it is ready and removes it otherwise.
+func (s *Server) heartbeat(ctx context.Context, serving bool) {
+	if !s.ready.Load() {
+		return
+	}
+
+	if serving {
+		if err := s.register(ctx); err != nil {
+			logger.Errorf("register: %v", err)
+		}
+
+		return
+	}
+
+	if err := s.deregister(ctx); err != nil {
+		logger.Errorf("deregister: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package resolver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+type (
+	// cacheEntry is the last known good addresses of a target persisted on disk.
+	cacheEntry struct {
+		UpdatedAt time.Time        `json:"updated_at"`
+		Instances []cachedInstance `json:"instances"`
+	}
+
+	cachedInstance struct {
+		Address string `json:"address"`
+		Weight  uint32 `json:"weight"`
+		Zone    string `json:"zone,omitempty"`
+		Version string `json:"version,omitempty"`
+	}
+)
+
+func cacheFile(dir, target string) string {
+	return filepath.Join(dir, url.PathEscape(target)+".json")
+}
+
+// loadCache returns addresses saved by saveCache, nothing if the file doesn't exist.
+func loadCache(path, serverName string) ([]resolver.Address, time.Time, error) {
+	bytes, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	var entry cacheEntry
+	if err = json.Unmarshal(bytes, &entry); err != nil {
+		return nil, time.Time{}, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	addresses := make([]resolver.Address, 0, len(entry.Instances))
+	for _, instance := range entry.Instances {
+		addresses = append(addresses, SetInstance(resolver.Address{
+			Addr:       instance.Address,
+			ServerName: serverName,
+		}, Instance{
+			Weight:  instance.Weight,
+			Zone:    instance.Zone,
+			Version: instance.Version,
+		}))
+	}
+
+	return addresses, entry.UpdatedAt, nil
+}
+
+// saveCache replaces the file atomically, so a crash doesn't leave it partially written.
+func saveCache(path string, addresses []resolver.Address) error {
+	entry := cacheEntry{
+		UpdatedAt: time.Now(),
+		Instances: make([]cachedInstance, 0, len(addresses)),
+	}
+	for _, addr := range addresses {
+		instance := GetInstance(addr)
+		entry.Instances = append(entry.Instances, cachedInstance{
+			Address: addr.Addr,
+			Weight:  instance.Weight,
+			Zone:    instance.Zone,
+			Version: instance.Version,
+		})
+	}
+
+	bytes, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("os.MkdirAll: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("os.CreateTemp: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(bytes); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("file.Write: %w", err)
+	}
+
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("file.Close: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("os.Rename: %w", err)
+	}
+
+	return nil
+}
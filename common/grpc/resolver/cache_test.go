@@ -0,0 +1,127 @@
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestCache(t *testing.T) {
+	addresses := []resolver.Address{
+		SetInstance(resolver.Address{Addr: "10.0.0.1:8000", ServerName: "users-service"}, Instance{
+			Weight:  2,
+			Zone:    "zone-a",
+			Version: "v1.2.0",
+		}),
+		// without instance attributes, e.g. the address set by UpdateAddress
+		{Addr: "10.0.0.2:8000", ServerName: "users-service"},
+	}
+
+	t.Run("saved addresses are loaded", func(t *testing.T) {
+		path := cacheFile(filepath.Join(t.TempDir(), "cache"), "users-service")
+
+		before := time.Now()
+		require.NoError(t, saveCache(path, addresses))
+
+		loaded, updatedAt, err := loadCache(path, "users-service")
+		require.NoError(t, err)
+		require.Len(t, loaded, 2)
+		for i := range addresses {
+			require.Equal(t, addresses[i].Addr, loaded[i].Addr)
+			require.Equal(t, addresses[i].ServerName, loaded[i].ServerName)
+			require.Equal(t, GetInstance(addresses[i]), GetInstance(loaded[i]))
+		}
+		require.WithinRange(t, updatedAt, before, time.Now())
+	})
+
+	t.Run("file is replaced", func(t *testing.T) {
+		dir := t.TempDir()
+		path := cacheFile(dir, "users-service")
+
+		require.NoError(t, saveCache(path, addresses))
+		require.NoError(t, saveCache(path, addresses[:1]))
+
+		loaded, _, err := loadCache(path, "users-service")
+		require.NoError(t, err)
+		require.True(t, equalAddresses(addresses[:1], loaded))
+
+		// temporary files are removed
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		loaded, updatedAt, err := loadCache(cacheFile(t.TempDir(), "users-service"), "users-service")
+		require.NoError(t, err)
+		require.Empty(t, loaded)
+		require.True(t, updatedAt.IsZero())
+	})
+
+	t.Run("corrupted file", func(t *testing.T) {
+		path := cacheFile(t.TempDir(), "users-service")
+		require.NoError(t, os.WriteFile(path, []byte(`{"instances": [`), 0o644))
+
+		_, _, err := loadCache(path, "users-service")
+		require.Error(t, err)
+	})
+
+	t.Run("target is escaped", func(t *testing.T) {
+		dir := t.TempDir()
+		path := cacheFile(dir, "../users-service")
+		require.Equal(t, dir, filepath.Dir(path))
+
+		require.NoError(t, saveCache(path, addresses))
+		_, err := os.Stat(path)
+		require.NoError(t, err)
+	})
+}
+
+type testClientConn struct {
+	resolver.ClientConn
+	states []resolver.State
+}
+
+func (cc *testClientConn) UpdateState(state resolver.State) error {
+	cc.states = append(cc.states, state)
+	return nil
+}
+
+func TestBuilder_SetCacheDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, saveCache(cacheFile(dir, "users-service"), []resolver.Address{{Addr: "10.0.0.1:8000"}}))
+
+	b := &Builder{}
+
+	// resolvers were built before the cache dir was set, e.g. by clients dialed in earlier server options
+	unresolved := &Resolver{target: "users-service", cc: &testClientConn{}, builder: b}
+	resolved := &Resolver{target: "users-service", cc: &testClientConn{}, builder: b, addresses: []resolver.Address{{Addr: "10.0.0.2:8000"}}}
+	other := &Resolver{target: "users-service", cc: &testClientConn{}, builder: &Builder{}}
+	for _, r := range []*Resolver{unresolved, resolved, other} {
+		resolvers.Store(r, struct{}{})
+		t.Cleanup(func() { resolvers.Delete(r) })
+	}
+
+	b.SetCacheDir(dir)
+
+	t.Run("cached addresses are used until resolved", func(t *testing.T) {
+		require.Equal(t, cacheFile(dir, "users-service"), unresolved.cacheFile)
+		require.Len(t, unresolved.cc.(*testClientConn).states, 1)
+		require.Equal(t, "10.0.0.1:8000", unresolved.cc.(*testClientConn).states[0].Addresses[0].Addr)
+	})
+
+	t.Run("resolved addresses are kept", func(t *testing.T) {
+		require.Equal(t, cacheFile(dir, "users-service"), resolved.cacheFile)
+		require.Empty(t, resolved.cc.(*testClientConn).states)
+		require.Equal(t, "10.0.0.2:8000", resolved.addresses[0].Addr)
+	})
+
+	t.Run("resolvers of other builders aren't changed", func(t *testing.T) {
+		require.Empty(t, other.cacheFile)
+		require.Empty(t, other.cc.(*testClientConn).states)
+	})
+}
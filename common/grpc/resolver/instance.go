@@ -0,0 +1,28 @@
+package resolver
+
+import "google.golang.org/grpc/resolver"
+
+type instanceKey struct{}
+
+// Instance attributes of a resolved address, used by load balancers.
+type Instance struct {
+	Weight  uint32
+	Zone    string
+	Version string
+}
+
+// SetInstance returns the address with instance attributes.
+func SetInstance(addr resolver.Address, instance Instance) resolver.Address {
+	addr.BalancerAttributes = addr.BalancerAttributes.WithValue(instanceKey{}, instance)
+	return addr
+}
+
+// GetInstance returns instance attributes of the address, weight defaults to 1.
+func GetInstance(addr resolver.Address) Instance {
+	instance, _ := addr.BalancerAttributes.Value(instanceKey{}).(Instance)
+	if instance.Weight == 0 {
+		instance.Weight = 1
+	}
+
+	return instance
+}
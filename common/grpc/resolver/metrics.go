@@ -0,0 +1,45 @@
+package resolver
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// resolvers are the open resolvers, their staleness is collected on scrape.
+var resolvers sync.Map
+
+type stalenessCollector struct {
+	desc *prometheus.Desc
+}
+
+// NewCollector exports grpc_resolver_staleness_seconds: seconds since addresses of the target
+// were confirmed by domain-name-service, 0 while the WatchAddresses stream is connected.
+func NewCollector(constLabels prometheus.Labels) prometheus.Collector {
+	return &stalenessCollector{
+		desc: prometheus.NewDesc(
+			"grpc_resolver_staleness_seconds",
+			"Seconds since addresses of the target were confirmed by domain-name-service",
+			[]string{"grpc_target"},
+			constLabels,
+		),
+	}
+}
+
+func (c *stalenessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *stalenessCollector) Collect(ch chan<- prometheus.Metric) {
+	// several connections to the same target report the most stale one
+	staleness := make(map[string]float64)
+	resolvers.Range(func(key, _ any) bool {
+		r := key.(*Resolver)
+		staleness[r.metricsTarget] = max(staleness[r.metricsTarget], r.staleness().Seconds())
+		return true
+	})
+
+	for target, value := range staleness {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, value, target)
+	}
+}
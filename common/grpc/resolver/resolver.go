@@ -3,14 +3,25 @@ package resolver
 import (
 	"context"
 	"fmt"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/resolver"
-	"log"
+	"google.golang.org/grpc/status"
+	"math/rand"
 	"os"
 	"strings"
 	"sync"
 	"time"
 
 	domain_name_service "github.com/fidesy/sdk/common/grpc/pkg/domain-name-service"
+	"github.com/fidesy/sdk/common/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// pollInterval is used if domain-name-service doesn't support WatchAddresses
+	pollInterval   = time.Second
+	initialBackoff = time.Second
+	maxBackoff     = 30 * time.Second
 )
 
 type Resolver struct {
@@ -20,60 +31,372 @@ type Resolver struct {
 	wg     sync.WaitGroup
 	cc     resolver.ClientConn
 
+	resolveNow chan struct{}
+
 	domainNameService domain_name_service.DomainNameServiceClient
+	builder           *Builder
+	metricsTarget     string
+
+	// updateMu serializes updates of ClientConn state and guards the cache file
+	updateMu sync.Mutex
+	// last known good addresses are persisted to the file if it is set
+	cacheFile  string
+	cacheSaved bool
+
+	mu sync.Mutex
+	// last known good addresses
+	addresses []resolver.Address
+	// WatchAddresses stream is connected and addresses are up to date
+	live        bool
+	confirmedAt time.Time
 }
 
+// ResolveNow is called by grpc when connections fail, addresses are requested again without waiting for changes.
 func (r *Resolver) ResolveNow(options resolver.ResolveNowOptions) {
-	//TODO implement me
-	return
+	select {
+	case r.resolveNow <- struct{}{}:
+	default:
+	}
 }
 
 func (r *Resolver) Close() {
-	//TODO implement me
-	return
+	r.cancel()
+	r.wg.Wait()
+	resolvers.Delete(r)
 }
 
+// watch receives address changes by WatchAddresses stream, it is reconnected with backoff
+// and addresses are polled while it is unavailable.
 func (r *Resolver) watch() {
 	defer r.wg.Done()
-	r.lookup(r.target)
-	ticker := time.NewTicker(1 * time.Second)
+
+	target := serviceName(r.target)
+
+	for attempt := 0; ; attempt++ {
+		received, err := r.watchAddresses(target)
+		if r.ctx.Err() != nil {
+			return
+		}
+
+		if status.Code(err) == codes.Unimplemented {
+			logger.Info("domain-name-service doesn't support WatchAddresses, polling", zap.String("target", target))
+			r.poll(target)
+			return
+		}
+
+		if received {
+			attempt = 0
+		}
+
+		logger.Errorf("domainNameServiceClient.WatchAddresses: %v", err, zap.String("target", target))
+
+		r.lookup(target)
+
+		if !r.wait(backoff(attempt)) {
+			return
+		}
+	}
+}
+
+// watchAddresses updates addresses until the stream fails, received reports whether any update was received.
+// ResolveNow looks addresses up once and keeps the stream open.
+func (r *Resolver) watchAddresses(target string) (received bool, err error) {
+	ctx, cancel := context.WithCancel(r.ctx)
+	defer cancel()
+
+	stream, err := r.domainNameService.WatchAddresses(ctx, &domain_name_service.WatchAddressesRequest{
+		ServiceName: target,
+	})
+	if err != nil {
+		return false, err
+	}
+	defer r.setLive(false)
+
+	// updates are received in the goroutine, so addresses are updated by this one only
+	responses := make(chan *domain_name_service.WatchAddressesResponse)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			select {
+			case responses <- resp:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
 	for {
+		select {
+		case resp := <-responses:
+			received = true
+			r.setLive(true)
+			r.update(target, instancesToAddresses(target, resp.GetInstances()))
+		case err := <-errs:
+			return received, err
+		case <-r.resolveNow:
+			r.lookup(target)
+		}
+	}
+}
+
+func (r *Resolver) poll(target string) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.lookup(target)
+
 		select {
 		case <-r.ctx.Done():
 			return
 		case <-ticker.C:
-			r.lookup(r.target)
+		case <-r.resolveNow:
 		}
 	}
 }
 
+// wait returns false if the resolver is closed, ResolveNow stops waiting.
+func (r *Resolver) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-r.ctx.Done():
+		return false
+	case <-timer.C:
+	case <-r.resolveNow:
+	}
+
+	return true
+}
+
 func (r *Resolver) lookup(target string) {
-	// Using different service names for different environments
-	env := strings.ToUpper(os.Getenv("ENV"))
-	if env == "LOCAL" || env == "STAGING" {
-		target = fmt.Sprintf("%s-stage", target)
+	addresses, err := r.lookupInstances(target)
+	if err != nil {
+		r.reportError(target, fmt.Errorf("target %s: %w", target, err))
+		return
 	}
 
-	addressResp, err := r.domainNameService.GetAddress(context.TODO(), &domain_name_service.GetAddressRequest{
-		ServiceName: target,
+	r.update(target, addresses)
+}
+
+func (r *Resolver) update(target string, addresses []resolver.Address) {
+	// no instances are registered, fall back to the address set by UpdateAddress
+	if len(addresses) == 0 {
+		var err error
+		addresses, err = r.lookupAddress(target)
+		if err != nil {
+			r.reportError(target, fmt.Errorf("target %s: %w", target, err))
+			return
+		}
+	}
+
+	// service is deregistered, keep the last known addresses until a new one is registered
+	if len(addresses) == 0 {
+		r.reportError(target, fmt.Errorf("target %s: no address registered", target))
+		return
+	}
+
+	r.updateMu.Lock()
+	defer r.updateMu.Unlock()
+
+	err := r.cc.UpdateState(resolver.State{
+		Addresses: addresses,
 	})
 	if err != nil {
-		log.Printf("domainNameServiceClient for target %s: %v", target, err)
+		logger.Errorf("cc.UpdateState: %v", err, zap.String("target", target))
 		return
 	}
 
-	address := resolver.Address{
-		Addr:       addressResp.GetAddress(),
-		ServerName: target,
+	r.mu.Lock()
+	changed := !equalAddresses(r.addresses, addresses)
+	r.addresses = addresses
+	r.confirmedAt = time.Now()
+	r.mu.Unlock()
+
+	// the file is rewritten once after start even without changes to refresh its time
+	if r.cacheFile != "" && (changed || !r.cacheSaved) {
+		if err = saveCache(r.cacheFile, addresses); err != nil {
+			logger.Errorf("saveCache: %v", err, zap.String("target", target))
+			return
+		}
+
+		r.cacheSaved = true
+	}
+}
+
+// useCache persists addresses to the file in the directory, addresses saved there are used
+// until domain-name-service resolves the target.
+func (r *Resolver) useCache(dir string) {
+	r.updateMu.Lock()
+	defer r.updateMu.Unlock()
+
+	if dir == "" || r.cacheFile != "" {
+		return
 	}
-	// Обновляем адреса в ClientConn
+
+	r.cacheFile = cacheFile(dir, r.target)
+
+	r.mu.Lock()
+	resolved := len(r.addresses) > 0
+	r.mu.Unlock()
+
+	if !resolved {
+		r.loadCache()
+	}
+}
+
+// loadCache passes addresses saved on disk to ClientConn, so it can connect while domain-name-service is unavailable.
+// It is called with updateMu held.
+func (r *Resolver) loadCache() {
+	addresses, updatedAt, err := loadCache(r.cacheFile, serviceName(r.target))
+	if err != nil {
+		logger.Errorf("loadCache: %v", err, zap.String("target", r.target))
+		return
+	}
+
+	if len(addresses) == 0 {
+		return
+	}
+
 	err = r.cc.UpdateState(resolver.State{
-		Addresses: []resolver.Address{
-			address,
-		},
+		Addresses: addresses,
 	})
 	if err != nil {
-		log.Println(err)
+		logger.Errorf("cc.UpdateState: %v", err, zap.String("target", r.target))
 		return
 	}
+
+	r.mu.Lock()
+	r.addresses = addresses
+	r.confirmedAt = updatedAt
+	r.mu.Unlock()
+}
+
+// reportError fails calls of ClientConn only if there are no last known good addresses to use.
+func (r *Resolver) reportError(target string, err error) {
+	logger.Errorf("domainNameServiceClient: %v", err, zap.String("target", target))
+
+	r.mu.Lock()
+	resolved := len(r.addresses) > 0
+	r.mu.Unlock()
+
+	if !resolved {
+		r.cc.ReportError(err)
+	}
+}
+
+func (r *Resolver) setLive(live bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// addresses were up to date until the stream is disconnected
+	if r.live && !live {
+		r.confirmedAt = time.Now()
+	}
+
+	r.live = live
+}
+
+func (r *Resolver) staleness() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.live {
+		return 0
+	}
+
+	return time.Since(r.confirmedAt)
+}
+
+// lookupInstances returns all healthy instances, nothing if domain-name-service doesn't support them yet.
+func (r *Resolver) lookupInstances(target string) ([]resolver.Address, error) {
+	resp, err := r.domainNameService.GetInstances(r.ctx, &domain_name_service.GetInstancesRequest{
+		ServiceName: target,
+	})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("GetInstances: %w", err)
+	}
+
+	return instancesToAddresses(target, resp.GetInstances()), nil
+}
+
+func (r *Resolver) lookupAddress(target string) ([]resolver.Address, error) {
+	resp, err := r.domainNameService.GetAddress(r.ctx, &domain_name_service.GetAddressRequest{
+		ServiceName: target,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetAddress: %w", err)
+	}
+
+	if resp.GetAddress() == "" {
+		return nil, nil
+	}
+
+	return []resolver.Address{{
+		Addr:       resp.GetAddress(),
+		ServerName: target,
+	}}, nil
+}
+
+func instancesToAddresses(target string, instances []*domain_name_service.Instance) []resolver.Address {
+	addresses := make([]resolver.Address, 0, len(instances))
+	for _, instance := range instances {
+		addresses = append(addresses, SetInstance(resolver.Address{
+			Addr:       instance.GetAddress(),
+			ServerName: target,
+		}, Instance{
+			Weight:  instance.GetWeight(),
+			Zone:    instance.GetZone(),
+			Version: instance.GetVersion(),
+		}))
+	}
+
+	return addresses
+}
+
+// serviceName returns the name of the service in the current environment.
+func serviceName(target string) string {
+	// Using different service names for different environments
+	env := strings.ToUpper(os.Getenv("ENV"))
+	if env == "LOCAL" || env == "STAGING" {
+		return fmt.Sprintf("%s-stage", target)
+	}
+
+	return target
+}
+
+// backoff returns exponential delay with jitter of the reconnect attempt.
+func backoff(attempt int) time.Duration {
+	delay := maxBackoff
+	if attempt < 16 {
+		delay = min(initialBackoff<<attempt, maxBackoff)
+	}
+
+	// +-20%
+	return time.Duration(float64(delay) * (0.8 + 0.4*rand.Float64()))
+}
+
+func equalAddresses(a, b []resolver.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !a[i].Equal(b[i]) || GetInstance(a[i]) != GetInstance(b[i]) {
+			return false
+		}
+	}
+
+	return true
 }
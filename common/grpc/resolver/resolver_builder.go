@@ -4,12 +4,40 @@ import (
 	"context"
 	"google.golang.org/grpc/resolver"
 	"sync"
+	"time"
 
 	domain_name_service "github.com/fidesy/sdk/common/grpc/pkg/domain-name-service"
 )
 
 type Builder struct {
 	DomainNameService domain_name_service.DomainNameServiceClient
+	// Directory to persist the last known good addresses in, so they are used
+	// after restart while domain-name-service is unavailable. Empty disables it.
+	// Use SetCacheDir once the builder is registered
+	CacheDir string
+
+	mu sync.Mutex
+}
+
+// SetCacheDir enables the disk cache, resolvers already built by the builder start using it too.
+func (b *Builder) SetCacheDir(dir string) {
+	b.mu.Lock()
+	b.CacheDir = dir
+	b.mu.Unlock()
+
+	resolvers.Range(func(key, _ any) bool {
+		if r := key.(*Resolver); r.builder == b {
+			r.useCache(dir)
+		}
+		return true
+	})
+}
+
+func (b *Builder) cacheDir() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.CacheDir
 }
 
 func (b *Builder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
@@ -20,10 +48,19 @@ func (b *Builder) Build(target resolver.Target, cc resolver.ClientConn, opts res
 		cancel:            cancel,
 		wg:                sync.WaitGroup{},
 		cc:                cc,
+		resolveNow:        make(chan struct{}, 1),
 		domainNameService: b.DomainNameService,
+		builder:           b,
+		metricsTarget:     target.URL.String(),
+		confirmedAt:       time.Now(),
 	}
+
+	// stored first, so the cache dir set concurrently by SetCacheDir isn't missed
+	resolvers.Store(r, struct{}{})
+	r.useCache(b.cacheDir())
+
 	r.wg.Add(1)
-	// Та самая горутина, которая в фоне будет обновлять адреса
+	// addresses are updated in the background until Close
 	go r.watch()
 	return r, nil
 }
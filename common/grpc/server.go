@@ -2,22 +2,28 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/fidesy/sdk/common/grpc/auth"
 	"github.com/fidesy/sdk/common/grpc/config"
 	grpcResolver "github.com/fidesy/sdk/common/grpc/resolver"
 	randomCommon "github.com/fidesy/sdk/common/random"
 	realtime_configs_service "github.com/fidesy/sdk/services/realtime-configs-service/pkg/realtime-configs-service"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
-	"github.com/rs/cors"
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/resolver"
 
-	domain_name_service "github.com/fidesy/sdk/common/grpc/pkg/domain-name-service"
 	"github.com/fidesy/sdk/common/logger"
 	gsyslog "github.com/hashicorp/go-syslog"
 	"google.golang.org/grpc"
@@ -30,9 +36,59 @@ type (
 	Server       struct {
 		port        string
 		metricsPort string
-		proxyPort   string
-		proxyRouter *runtime.ServeMux
 		swaggerPort string
+
+		openAPISpecs   [][]byte
+		swaggerHandler http.Handler
+
+		httpTimeouts  HTTPTimeouts
+		adminHandlers []adminHandler
+		adminToken    string
+
+		proxyPort      string
+		proxyRouter    *runtime.ServeMux
+		proxyHeaders   []string
+		gatewayOptions []runtime.ServeMuxOption
+		cors           *corsPolicy
+
+		singlePort         bool
+		singlePortRequests sync.WaitGroup
+
+		shutdownTimeout time.Duration
+		shutdownHooks   []ShutdownHook
+		httpServers     []*http.Server
+		ready           atomic.Bool
+		readyCh         chan struct{}
+
+		healthServer *health.Server
+		healthChecks map[string]HealthCheck
+
+		unaryInterceptors  []grpc.UnaryServerInterceptor
+		streamInterceptors []grpc.StreamServerInterceptor
+		serverOptions      []grpc.ServerOption
+
+		disableRecovery bool
+		recoveryHandler RecoveryHandler
+
+		tlsConfig *tls.Config
+
+		authenticator auth.Authenticator
+		authPolicies  auth.Policies
+
+		rateLimiter *rateLimiter
+
+		disableValidation bool
+
+		accessLog *AccessLogConfig
+
+		metricsConfig MetricsConfig
+		metrics       *grpcMetrics
+		registry      *prometheus.Registry
+
+		instance                InstanceConfig
+		legacyAddressRegistered atomic.Bool
+		resolverBuilder         *grpcResolver.Builder
+		resolverCacheDir        string
 	}
 	ServiceDescriptor interface {
 		GetDescription() *grpc.ServiceDesc
@@ -56,7 +112,6 @@ func WithMetricsPort(metricsPort string) ServerOption {
 func WithProxyPort(proxyPort string) ServerOption {
 	return func(s *Server) error {
 		s.proxyPort = proxyPort
-		s.proxyRouter = runtime.NewServeMux()
 		return nil
 	}
 }
@@ -83,40 +138,56 @@ func WithGraylog(graylogHost string) ServerOption {
 	}
 }
 
-func WithTracer(jaegerEndpoint string) ServerOption {
+// WithTracer exports spans to OpenTelemetry collector by OTLP, e.g. "otel-collector:4317".
+func WithTracer(otlpEndpoint string, options ...TracerOption) ServerOption {
 	return func(s *Server) error {
-		var err error
-		_, closer, err = NewTracer(jaegerEndpoint)
+		_, _, err := NewTracer(otlpEndpoint, options...)
 		if err != nil {
-			return fmt.Errorf("config.NewJaegerTracer: %v", err)
+			return fmt.Errorf("NewTracer: %v", err)
 		}
 
 		return nil
 	}
 }
 
-func WithDomainNameService(ctx context.Context, dnsHost string) ServerOption {
+func WithDomainNameService(ctx context.Context, dnsHost string, options ...ClientOption) ServerOption {
 	return func(s *Server) error {
-		err := NewDomainNameService(ctx, dnsHost)
+		err := NewDomainNameService(ctx, dnsHost, options...)
 		if err != nil {
 			return fmt.Errorf("NewDomainNameService: %w", err)
 		}
 
-		rb := &grpcResolver.Builder{
+		s.resolverBuilder = &grpcResolver.Builder{
 			DomainNameService: domainNameServiceClient,
+			CacheDir:          s.resolverCacheDir,
 		}
-		resolver.Register(rb)
+		resolver.Register(s.resolverBuilder)
 
 		return nil
 	}
 }
 
-func WithRealtimeConfigsService(ctx context.Context, dnsHost string) ServerOption {
+// WithResolverCache persists addresses resolved by domain-name-service to the directory,
+// so that after restart services are reachable while domain-name-service is unavailable.
+// Clients dialed by options applied before it use the cache too.
+func WithResolverCache(dir string) ServerOption {
+	return func(s *Server) error {
+		s.resolverCacheDir = dir
+		if s.resolverBuilder != nil {
+			s.resolverBuilder.SetCacheDir(dir)
+		}
+
+		return nil
+	}
+}
+
+func WithRealtimeConfigsService(ctx context.Context, dnsHost string, options ...ClientOption) ServerOption {
 	return func(s *Server) error {
 		client, err := NewClient[realtime_configs_service.RealtimeConfigsServiceClient](
 			ctx,
 			realtime_configs_service.NewRealtimeConfigsServiceClient,
 			dnsHost,
+			options...,
 		)
 		if err != nil {
 			return fmt.Errorf("NewRealtimeConfigsService: %w", err)
@@ -133,9 +204,14 @@ func NewServer(options ...ServerOption) (*Server, error) {
 		panic("APP_NAME env variable is required")
 	}
 
-	s := &Server{}
-
-	initMetrics()
+	s := &Server{
+		readyCh:         make(chan struct{}),
+		proxyHeaders:    append([]string{requestIDHeader}, propagationHeaders...),
+		shutdownTimeout: defaultShutdownTimeout,
+		httpTimeouts:    defaultHTTPTimeouts,
+		healthChecks:    make(map[string]HealthCheck),
+		recoveryHandler: defaultRecoveryHandler,
+	}
 
 	for _, opt := range options {
 		err := opt(s)
@@ -144,29 +220,56 @@ func NewServer(options ...ServerOption) (*Server, error) {
 		}
 	}
 
+	s.initMetrics()
+
+	if s.singlePort && (s.metricsPort != "" || s.proxyPort != "" || s.swaggerPort != "") {
+		return nil, errors.New("WithSinglePort can't be used with WithMetricsPort, WithProxyPort or WithSwaggerPort")
+	}
+
+	if s.proxyPort != "" || s.singlePort {
+		s.proxyRouter = s.newProxyRouter()
+	}
+
 	s.fillInDefaultValues()
 
+	if s.resolverBuilder != nil {
+		if err := s.resolveInstanceAddress(); err != nil {
+			return nil, fmt.Errorf("resolveInstanceAddress: %w", err)
+		}
+	}
+
 	return s, nil
 }
 
 func (s *Server) Run(ctx context.Context, descs ...ServiceDescriptor) error {
 	defer s.shutDown()
 
-	interceptors := []grpc.UnaryServerInterceptor{
-		metricsInterceptor(),
+	grpcServer := grpc.NewServer(s.grpcServerOptions()...)
+
+	for _, desc := range descs {
+		grpcServer.RegisterService(desc.GetDescription(), desc)
 	}
-	if tracer != nil {
-		interceptors = append(interceptors, tracingInterceptor())
+
+	s.registerHealthServer(grpcServer)
+
+	if s.swaggerPort != "" || s.singlePort {
+		swaggerHandler, err := s.newSwaggerHandler(descs)
+		if err != nil {
+			return fmt.Errorf("newSwaggerHandler: %w", err)
+		}
+
+		s.swaggerHandler = swaggerHandler
 	}
 
-	grpcServer := grpc.NewServer(
-		grpc.ChainUnaryInterceptor(
-			interceptors...,
-		),
-	)
+	var gatewayLis net.Listener
+	if registrars := s.gatewayRegistrars(descs); len(registrars) > 0 {
+		conn, lis, err := s.registerGateway(ctx, grpcServer, registrars)
+		if err != nil {
+			return fmt.Errorf("registerGateway: %w", err)
+		}
+		defer conn.Close()
 
-	for _, desc := range descs {
-		grpcServer.RegisterService(desc.GetDescription(), desc)
+		gatewayLis = lis
 	}
 
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", s.port))
@@ -174,30 +277,100 @@ func (s *Server) Run(ctx context.Context, descs ...ServiceDescriptor) error {
 		return fmt.Errorf("net.Listen: %w", err)
 	}
 
-	errGroup := errgroup.Group{}
+	serve := func() error {
+		if err := grpcServer.Serve(lis); err != nil {
+			return fmt.Errorf("grpcServer.Serve: %w", err)
+		}
+
+		return nil
+	}
 
-	errGroup.Go(func() error {
-		if domainNameServiceClient != nil {
-			_, err = domainNameServiceClient.UpdateAddress(ctx, &domain_name_service.UpdateAddressRequest{
-				ServiceName: appName,
-				Address:     fmt.Sprintf("%s:%s", appName, s.port),
-			})
-			if err != nil {
-				return fmt.Errorf("domainNameServiceClient.UpdatePort: %w", err)
+	if s.singlePort {
+		server, err := s.newSinglePortServer(grpcServer)
+		if err != nil {
+			return fmt.Errorf("newSinglePortServer: %w", err)
+		}
+		s.httpServers = append(s.httpServers, server)
+
+		serve = func() error {
+			if err := s.serveSinglePort(server, lis); err != nil {
+				return fmt.Errorf("serveSinglePort: %w", err)
 			}
+
+			return nil
 		}
+	}
 
-		logger.Info(fmt.Sprintf("grpcServer is running at %s port", s.port))
-		if err = grpcServer.Serve(lis); err != nil {
-			return fmt.Errorf("grpcServer.Serve: %w", err)
+	errGroup, groupCtx := errgroup.WithContext(ctx)
+
+	errGroup.Go(func() error {
+		if err := s.register(groupCtx); err != nil {
+			return fmt.Errorf("register: %w", err)
 		}
 
+		s.ready.Store(true)
+		close(s.readyCh)
+
+		logger.Info(fmt.Sprintf("grpcServer is running at %s port", s.port))
+
+		return serve()
+	})
+
+	errGroup.Go(func() error {
+		s.watchHealth(groupCtx, grpcServer)
 		return nil
 	})
 
+	if gatewayLis != nil {
+		errGroup.Go(func() error {
+			if err := grpcServer.Serve(gatewayLis); err != nil {
+				return fmt.Errorf("gateway grpcServer.Serve: %w", err)
+			}
+
+			return nil
+		})
+	}
+
+	if s.rateLimiter != nil {
+		errGroup.Go(func() error {
+			s.rateLimiter.run(groupCtx)
+			return nil
+		})
+	}
+
+	if s.cors != nil && s.cors.config.OriginsConfigKey != "" {
+		errGroup.Go(func() error {
+			s.cors.run(groupCtx)
+			return nil
+		})
+	}
+
+	if !s.singlePort {
+		s.runHTTPServers(errGroup)
+	}
+
+	errGroup.Go(func() error {
+		<-groupCtx.Done()
+		s.stop(grpcServer)
+
+		return nil
+	})
+
+	return errGroup.Wait()
+}
+
+// runHTTPServers starts metrics, proxy and swagger servers on their own ports.
+func (s *Server) runHTTPServers(errGroup *errgroup.Group) {
+	metricsMux := http.NewServeMux()
+	s.registerMetricsHandlers(metricsMux)
+	s.registerAdminHandlers(metricsMux)
+	metricsServer := s.newHTTPServer(s.metricsPort, metricsMux)
+	// pprof profiles and traces are written for up to minutes
+	metricsServer.WriteTimeout = 0
+
 	errGroup.Go(func() error {
 		logger.Info(fmt.Sprintf("metrics are running at %s port", s.metricsPort))
-		if err = runMetrics(ctx, s.metricsPort); err != nil {
+		if err := listenAndServe(metricsServer); err != nil {
 			return fmt.Errorf("runMetrics: %w", err)
 		}
 
@@ -205,40 +378,30 @@ func (s *Server) Run(ctx context.Context, descs ...ServiceDescriptor) error {
 	})
 
 	if s.proxyPort != "" {
-		errGroup.Go(func() error {
-			corsHandler := cors.New(cors.Options{
-				AllowedOrigins: []string{"*"},
-				AllowedMethods: []string{"GET", "POST", "PUT", "PATCH"},
-				AllowedHeaders: []string{"*"},
-				Debug:          false,
-			})
-
-			server := &http.Server{
-				Addr:    fmt.Sprintf(":%s", s.proxyPort),
-				Handler: corsHandler.Handler(s.proxyRouter),
-			}
+		proxyServer := s.newHTTPServer(s.proxyPort, s.proxyHandler())
 
-			if err = server.ListenAndServe(); err != nil {
+		errGroup.Go(func() error {
+			if err := listenAndServe(proxyServer); err != nil {
 				return fmt.Errorf("httpProxy: server.ListenAndServe: %w", err)
 			}
+
 			return nil
 		})
 	}
 
 	if s.swaggerPort != "" {
-		errGroup.Go(func() error {
-			fs := http.FileServer(http.Dir("./swaggerui"))
-			http.Handle("/docs/", http.StripPrefix("/docs/", fs))
+		swaggerMux := http.NewServeMux()
+		s.registerSwaggerHandlers(swaggerMux)
+		swaggerServer := s.newHTTPServer(s.swaggerPort, swaggerMux)
 
-			if err := http.ListenAndServe(fmt.Sprintf(":%s", s.swaggerPort), nil); err != nil {
+		errGroup.Go(func() error {
+			if err := listenAndServe(swaggerServer); err != nil {
 				return fmt.Errorf("swagger http.ListenAndServe: %w", err)
 			}
 
 			return nil
 		})
 	}
-
-	return errGroup.Wait()
 }
 
 func (s *Server) ProxyRouter() *runtime.ServeMux {
@@ -266,7 +429,7 @@ func (s *Server) fillInDefaultValues() {
 		s.port = randomPort
 	}
 
-	if s.metricsPort == "" {
+	if s.metricsPort == "" && !s.singlePort {
 		randomPort := randomCommon.RandomPort()
 		logger.Info(fmt.Sprintf("Using default metrics server port %s", randomPort))
 		s.metricsPort = randomPort
@@ -0,0 +1,107 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fidesy/sdk/common/logger"
+	"google.golang.org/grpc"
+)
+
+const defaultShutdownTimeout = 15 * time.Second
+
+// ShutdownHook is called when Server.Run stops, after every listener is closed.
+type ShutdownHook func(ctx context.Context) error
+
+// WithShutdownTimeout sets how long in-flight calls are drained before the server is stopped forcibly.
+func WithShutdownTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) error {
+		if timeout <= 0 {
+			return fmt.Errorf("shutdown timeout must be positive, got %s", timeout)
+		}
+
+		s.shutdownTimeout = timeout
+		return nil
+	}
+}
+
+// WithShutdownHook registers a hook, see Server.OnShutdown.
+func WithShutdownHook(hook ShutdownHook) ServerOption {
+	return func(s *Server) error {
+		s.OnShutdown(hook)
+		return nil
+	}
+}
+
+// OnShutdown registers a hook to release service resources (pools, consumers, producers).
+// Hooks are called in reverse order of registration.
+func (s *Server) OnShutdown(hook ShutdownHook) {
+	s.shutdownHooks = append(s.shutdownHooks, hook)
+}
+
+func (s *Server) stop(grpcServer *grpc.Server) {
+	logger.Info("shutting down server")
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	s.ready.Store(false)
+	s.healthServer.Shutdown()
+
+	if err := s.deregister(ctx); err != nil {
+		logger.Errorf("deregister: %v", err)
+	}
+
+	if s.singlePort {
+		s.stopSinglePort(ctx, grpcServer)
+	} else {
+		// gateway requests are drained before the grpc server they call
+		s.shutdownHTTPServers(ctx)
+		gracefulStop(ctx, grpcServer)
+	}
+
+	for i := len(s.shutdownHooks) - 1; i >= 0; i-- {
+		if err := s.shutdownHooks[i](ctx); err != nil {
+			logger.Errorf("shutdown hook: %v", err)
+		}
+	}
+
+	logger.Info("server is stopped")
+}
+
+func (s *Server) shutdownHTTPServers(ctx context.Context) {
+	for _, server := range s.httpServers {
+		if err := server.Shutdown(ctx); err != nil {
+			logger.Errorf("http.Server.Shutdown: %v", err)
+			_ = server.Close()
+		}
+	}
+}
+
+// gracefulStop waits for in-flight calls until ctx is done and then closes the remaining connections.
+func gracefulStop(ctx context.Context, grpcServer *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logger.Info("graceful stop timed out, forcing grpcServer.Stop")
+		grpcServer.Stop()
+	}
+}
+
+func listenAndServe(server *http.Server) error {
+	err := server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+)
+
+// WithSinglePort serves grpc, gateway, swagger (/docs/) and metrics (/metrics, /healthz, /readyz)
+// on the grpc port. Requests are routed by protocol and content type, plain text HTTP/2 (h2c) is supported
+// for grpc clients without TLS. WithMetricsPort, WithProxyPort and WithSwaggerPort can't be used with it.
+func WithSinglePort() ServerOption {
+	return func(s *Server) error {
+		s.singlePort = true
+		return nil
+	}
+}
+
+// newSinglePortServer returns http server routing grpc calls to grpcServer and the rest to http handlers.
+func (s *Server) newSinglePortServer(grpcServer *grpc.Server) (*http.Server, error) {
+	mux := http.NewServeMux()
+	s.registerMetricsHandlers(mux)
+	s.registerSwaggerHandlers(mux)
+	s.registerAdminHandlers(mux)
+	mux.Handle("/", s.proxyHandler())
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.singlePortRequests.Add(1)
+		defer s.singlePortRequests.Done()
+
+		if isGRPCRequest(r) {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+
+	server := &http.Server{
+		ReadHeaderTimeout: s.httpTimeouts.ReadHeader,
+		IdleTimeout:       s.httpTimeouts.Idle,
+	}
+
+	http2Server := &http2.Server{}
+	// closes h2c connections on server.Shutdown, they are hijacked and not tracked by http.Server
+	if err := http2.ConfigureServer(server, http2Server); err != nil {
+		return nil, err
+	}
+
+	if s.tlsConfig != nil {
+		server.Handler = handler
+	} else {
+		server.Handler = h2c.NewHandler(handler, http2Server)
+	}
+
+	return server, nil
+}
+
+// serveSinglePort serves server on lis, with TLS both HTTP/2 and HTTP/1.1 are negotiated by ALPN.
+func (s *Server) serveSinglePort(server *http.Server, lis net.Listener) error {
+	if s.tlsConfig != nil {
+		lis = tls.NewListener(lis, singlePortTLSConfig(s.tlsConfig))
+	}
+
+	err := server.Serve(lis)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// stopSinglePort waits for in-flight requests until ctx is done, grpc.Server.GracefulStop
+// doesn't support calls served by http server.
+func (s *Server) stopSinglePort(ctx context.Context, grpcServer *grpc.Server) {
+	s.shutdownHTTPServers(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		s.singlePortRequests.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	grpcServer.Stop()
+}
+
+func isGRPCRequest(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+func singlePortTLSConfig(config *tls.Config) *tls.Config {
+	config = config.Clone()
+
+	getConfigForClient := config.GetConfigForClient
+	config.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		clientConfig, err := getConfigForClient(hello)
+		if err != nil {
+			return nil, err
+		}
+
+		clientConfig.NextProtos = []string{"h2", "http/1.1"}
+
+		return clientConfig, nil
+	}
+
+	return config
+}
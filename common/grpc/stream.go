@@ -0,0 +1,25 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// wrappedServerStream overrides the context of a grpc.ServerStream,
+// so stream interceptors can pass values down to the handler.
+type wrappedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func wrapServerStream(stream grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &wrappedServerStream{
+		ServerStream: stream,
+		ctx:          ctx,
+	}
+}
+
+func (s *wrappedServerStream) Context() context.Context {
+	return s.ctx
+}
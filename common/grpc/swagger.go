@@ -0,0 +1,241 @@
+package grpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"path"
+
+	"github.com/fidesy/sdk/common/logger"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// swaggerInitializer replaces the petstore example of bundled Swagger UI.
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "./swagger.json",
+    dom_id: '#swagger-ui',
+    deepLinking: true,
+    presets: [
+      SwaggerUIBundle.presets.apis,
+      SwaggerUIStandalonePreset
+    ],
+    plugins: [
+      SwaggerUIBundle.plugins.DownloadUrl
+    ],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+// OpenAPIDescriptor is optionally implemented by ServiceDescriptor to add its spec to Swagger UI.
+type OpenAPIDescriptor interface {
+	GetOpenAPISpec() []byte
+}
+
+// WithOpenAPISpecs adds OpenAPI (swagger) JSON specs, e.g. generated by protoc-gen-openapiv2 and embedded.
+func WithOpenAPISpecs(specs ...[]byte) ServerOption {
+	return func(s *Server) error {
+		s.openAPISpecs = append(s.openAPISpecs, specs...)
+		return nil
+	}
+}
+
+// WithOpenAPIFS adds every *.json file of fsys as OpenAPI spec.
+func WithOpenAPIFS(fsys fs.FS) ServerOption {
+	return func(s *Server) error {
+		return fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if entry.IsDir() || path.Ext(name) != ".json" {
+				return nil
+			}
+
+			spec, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return fmt.Errorf("fs.ReadFile: %w", err)
+			}
+
+			s.openAPISpecs = append(s.openAPISpecs, spec)
+			return nil
+		})
+	}
+}
+
+// newSwaggerHandler serves bundled Swagger UI with specs of the server and services merged into one.
+// Without specs ./swaggerui directory is served as before.
+func (s *Server) newSwaggerHandler(descs []ServiceDescriptor) (http.Handler, error) {
+	specs := s.openAPISpecs
+	for _, desc := range descs {
+		if openAPIDesc, ok := desc.(OpenAPIDescriptor); ok {
+			specs = append(specs, openAPIDesc.GetOpenAPISpec())
+		}
+	}
+
+	if len(specs) == 0 {
+		return http.StripPrefix("/docs/", http.FileServer(http.Dir("./swaggerui"))), nil
+	}
+
+	spec, err := mergeOpenAPISpecs(specs)
+	if err != nil {
+		return nil, fmt.Errorf("mergeOpenAPISpecs: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/docs/", http.StripPrefix("/docs/", http.FileServer(http.FS(swaggerFiles.FS))))
+	mux.HandleFunc("/docs/swagger-initializer.js", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript")
+		_, _ = w.Write([]byte(swaggerInitializer))
+	})
+	mux.HandleFunc("/docs/swagger.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(s.rewriteOpenAPIHost(spec, r)); err != nil {
+			logger.Errorf("json.Encode: %v", err)
+		}
+	})
+
+	return mux, nil
+}
+
+func (s *Server) registerSwaggerHandlers(mux *http.ServeMux) {
+	mux.Handle("/docs/", s.swaggerHandler)
+}
+
+// rewriteOpenAPIHost points the spec to the gateway listener, as seen by the client of Swagger UI.
+// Without the gateway the spec is served as is.
+func (s *Server) rewriteOpenAPIHost(spec map[string]any, r *http.Request) map[string]any {
+	gatewayPort := s.proxyPort
+	if s.singlePort {
+		gatewayPort = s.port
+	}
+
+	if gatewayPort == "" {
+		return spec
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	host := r.Host
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+
+	host = net.JoinHostPort(host, gatewayPort)
+
+	// behind ingress the gateway is reachable by the public address only
+	if forwardedHost := r.Header.Get("X-Forwarded-Host"); forwardedHost != "" {
+		host = forwardedHost
+	}
+
+	if forwardedProto := r.Header.Get("X-Forwarded-Proto"); forwardedProto != "" {
+		scheme = forwardedProto
+	}
+
+	rewritten := make(map[string]any, len(spec)+2)
+	for key, value := range spec {
+		rewritten[key] = value
+	}
+
+	if _, ok := spec["openapi"]; ok {
+		rewritten["servers"] = []map[string]string{{"url": scheme + "://" + host}}
+	} else {
+		rewritten["host"] = host
+		rewritten["schemes"] = []string{scheme}
+	}
+
+	return rewritten
+}
+
+// mergeOpenAPISpecs merges paths, definitions and tags of specs, the first spec wins on conflicts.
+func mergeOpenAPISpecs(specs [][]byte) (map[string]any, error) {
+	var merged map[string]any
+
+	for i, raw := range specs {
+		var spec map[string]any
+		if err := json.Unmarshal(raw, &spec); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal spec %d: %w", i, err)
+		}
+
+		if merged == nil {
+			merged = spec
+			continue
+		}
+
+		for _, key := range []string{"paths", "definitions", "securityDefinitions", "parameters", "responses"} {
+			mergeObjects(merged, spec, key)
+		}
+
+		if components, ok := spec["components"].(map[string]any); ok {
+			mergedComponents, ok := merged["components"].(map[string]any)
+			if !ok {
+				mergedComponents = make(map[string]any)
+				merged["components"] = mergedComponents
+			}
+
+			for key := range components {
+				mergeObjects(mergedComponents, components, key)
+			}
+		}
+
+		mergeTags(merged, spec)
+	}
+
+	if len(specs) > 1 {
+		if info, ok := merged["info"].(map[string]any); ok {
+			info["title"] = appName
+		}
+	}
+
+	return merged, nil
+}
+
+func mergeObjects(dst, src map[string]any, key string) {
+	srcObject, ok := src[key].(map[string]any)
+	if !ok {
+		return
+	}
+
+	dstObject, ok := dst[key].(map[string]any)
+	if !ok {
+		dstObject = make(map[string]any, len(srcObject))
+		dst[key] = dstObject
+	}
+
+	for name, value := range srcObject {
+		if _, exists := dstObject[name]; exists {
+			logger.Info(fmt.Sprintf("openapi %s %s is defined twice, the first one is used", key, name))
+			continue
+		}
+
+		dstObject[name] = value
+	}
+}
+
+func mergeTags(dst, src map[string]any) {
+	srcTags, _ := src["tags"].([]any)
+	dstTags, _ := dst["tags"].([]any)
+
+	names := make(map[string]bool, len(dstTags))
+	for _, tag := range dstTags {
+		if tag, ok := tag.(map[string]any); ok {
+			names[fmt.Sprint(tag["name"])] = true
+		}
+	}
+
+	for _, tag := range srcTags {
+		if tag, ok := tag.(map[string]any); ok && !names[fmt.Sprint(tag["name"])] {
+			dstTags = append(dstTags, tag)
+		}
+	}
+
+	if len(dstTags) > 0 {
+		dst["tags"] = dstTags
+	}
+}
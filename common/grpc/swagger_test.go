@@ -0,0 +1,147 @@
+package grpc
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeOpenAPISpecs(t *testing.T) {
+	users := []byte(`{
+		"swagger": "2.0",
+		"info": {"title": "users.proto", "version": "1"},
+		"tags": [{"name": "UserService"}],
+		"paths": {"/v1/users": {"get": {"operationId": "ListUsers"}}},
+		"definitions": {
+			"User": {"type": "object"},
+			"rpcStatus": {"type": "object", "description": "users"}
+		}
+	}`)
+	orders := []byte(`{
+		"swagger": "2.0",
+		"info": {"title": "orders.proto", "version": "1"},
+		"tags": [{"name": "OrderService"}, {"name": "UserService"}],
+		"paths": {
+			"/v1/orders": {"get": {"operationId": "ListOrders"}},
+			"/v1/users": {"get": {"operationId": "Duplicate"}}
+		},
+		"definitions": {
+			"Order": {"type": "object"},
+			"rpcStatus": {"type": "object", "description": "orders"}
+		},
+		"securityDefinitions": {"ApiKey": {"type": "apiKey", "name": "x-api-key", "in": "header"}}
+	}`)
+
+	t.Run("single spec is kept as is", func(t *testing.T) {
+		merged, err := mergeOpenAPISpecs([][]byte{users})
+		require.NoError(t, err)
+		require.Equal(t, "users.proto", merged["info"].(map[string]any)["title"])
+	})
+
+	t.Run("specs are merged", func(t *testing.T) {
+		merged, err := mergeOpenAPISpecs([][]byte{users, orders})
+		require.NoError(t, err)
+
+		require.Equal(t, appName, merged["info"].(map[string]any)["title"])
+
+		paths := merged["paths"].(map[string]any)
+		require.Len(t, paths, 2)
+		// the first definition wins
+		require.Equal(t, "ListUsers", paths["/v1/users"].(map[string]any)["get"].(map[string]any)["operationId"])
+
+		definitions := merged["definitions"].(map[string]any)
+		require.Len(t, definitions, 3)
+		require.Equal(t, "users", definitions["rpcStatus"].(map[string]any)["description"])
+
+		require.Contains(t, merged["securityDefinitions"], "ApiKey")
+
+		require.Equal(t, []any{
+			map[string]any{"name": "UserService"},
+			map[string]any{"name": "OrderService"},
+		}, merged["tags"])
+	})
+
+	t.Run("openapi 3 components are merged", func(t *testing.T) {
+		merged, err := mergeOpenAPISpecs([][]byte{
+			[]byte(`{"openapi": "3.0.0", "info": {"title": "a"}, "components": {"schemas": {"User": {}}}}`),
+			[]byte(`{"openapi": "3.0.0", "info": {"title": "b"}, "components": {"schemas": {"Order": {}}, "securitySchemes": {"bearer": {}}}}`),
+		})
+		require.NoError(t, err)
+
+		components := merged["components"].(map[string]any)
+		require.Len(t, components["schemas"], 2)
+		require.Contains(t, components["securitySchemes"], "bearer")
+	})
+
+	t.Run("invalid spec", func(t *testing.T) {
+		_, err := mergeOpenAPISpecs([][]byte{users, []byte(`{`)})
+		require.Error(t, err)
+	})
+}
+
+func TestRewriteOpenAPIHost(t *testing.T) {
+	swagger := map[string]any{"swagger": "2.0"}
+	openapi := map[string]any{"openapi": "3.0.0"}
+
+	tests := []struct {
+		name    string
+		server  *Server
+		spec    map[string]any
+		host    string
+		headers map[string]string
+		want    map[string]any
+	}{
+		{
+			name:   "gateway port",
+			server: &Server{proxyPort: "8080"},
+			spec:   swagger,
+			host:   "example.com:8081",
+			want:   map[string]any{"swagger": "2.0", "host": "example.com:8080", "schemes": []string{"http"}},
+		},
+		{
+			name:   "single port",
+			server: &Server{port: "8000", singlePort: true},
+			spec:   swagger,
+			host:   "example.com:8000",
+			want:   map[string]any{"swagger": "2.0", "host": "example.com:8000", "schemes": []string{"http"}},
+		},
+		{
+			name:   "forwarded host",
+			server: &Server{proxyPort: "8080"},
+			spec:   swagger,
+			host:   "10.0.0.1:8081",
+			headers: map[string]string{
+				"X-Forwarded-Host":  "api.example.com",
+				"X-Forwarded-Proto": "https",
+			},
+			want: map[string]any{"swagger": "2.0", "host": "api.example.com", "schemes": []string{"https"}},
+		},
+		{
+			name:   "openapi 3",
+			server: &Server{proxyPort: "8080"},
+			spec:   openapi,
+			host:   "example.com:8081",
+			want:   map[string]any{"openapi": "3.0.0", "servers": []map[string]string{{"url": "http://example.com:8080"}}},
+		},
+		{
+			name:   "without gateway",
+			server: &Server{swaggerPort: "8081"},
+			spec:   swagger,
+			host:   "example.com:8081",
+			want:   swagger,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/swagger.json", nil)
+			r.Host = tt.host
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+
+			require.Equal(t, tt.want, tt.server.rewriteOpenAPIHost(tt.spec, r))
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fidesy/sdk/common/logger"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+const defaultCertReloadInterval = 30 * time.Second
+
+// TLSConfig describes certificate files used by the server and clients.
+// Files are checked for changes at most once per ReloadInterval and reloaded without restart.
+type TLSConfig struct {
+	// Server certificate, or client certificate for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// CA used to verify the other side: client certificates on server, server certificate on client.
+	// System roots are used on client when empty.
+	CAFile string
+	// Server only, reject clients without a certificate signed by CAFile.
+	RequireClientCert bool
+	// Client only, overrides the server name used for verification.
+	ServerName string
+	// Default 30s
+	ReloadInterval time.Duration
+}
+
+// WithTLS enables TLS for the grpc server, with RequireClientCert it is mutual TLS.
+func WithTLS(config TLSConfig) ServerOption {
+	return func(s *Server) error {
+		tlsConfig, err := config.serverConfig()
+		if err != nil {
+			return fmt.Errorf("TLSConfig.serverConfig: %w", err)
+		}
+
+		s.tlsConfig = tlsConfig
+		return nil
+	}
+}
+
+// ServiceIdentityFromContext returns identity of the caller from its verified certificate:
+// URI SAN (e.g. spiffe://cluster/ns/default/sa/users-service) if present, otherwise subject common name.
+func ServiceIdentityFromContext(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+
+	cert := tlsInfo.State.VerifiedChains[0][0]
+	if len(cert.URIs) > 0 {
+		return cert.URIs[0].String(), true
+	}
+
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName, true
+	}
+
+	return "", false
+}
+
+func (c TLSConfig) serverConfig() (*tls.Config, error) {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, errors.New("CertFile and KeyFile are required")
+	}
+
+	if c.RequireClientCert && c.CAFile == "" {
+		return nil, errors.New("CAFile is required to verify client certificates")
+	}
+
+	reloader, err := newCertReloader(c)
+	if err != nil {
+		return nil, fmt.Errorf("newCertReloader: %w", err)
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			reloader.maybeReload()
+
+			config := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*reloader.certificate()},
+				NextProtos:   []string{"h2"},
+			}
+
+			if caPool := reloader.caPool(); caPool != nil {
+				config.ClientCAs = caPool
+				config.ClientAuth = tls.VerifyClientCertIfGiven
+				if c.RequireClientCert {
+					config.ClientAuth = tls.RequireAndVerifyClientCert
+				}
+			}
+
+			return config, nil
+		},
+	}, nil
+}
+
+func (c TLSConfig) clientConfig() (*tls.Config, error) {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return nil, errors.New("both CertFile and KeyFile are required for client certificate")
+	}
+
+	reloader, err := newCertReloader(c)
+	if err != nil {
+		return nil, fmt.Errorf("newCertReloader: %w", err)
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: c.ServerName,
+	}
+
+	if c.CertFile != "" {
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			reloader.maybeReload()
+			return reloader.certificate(), nil
+		}
+	}
+
+	if c.CAFile != "" {
+		// Built-in verification can't pick up a reloaded CA, so the chain is verified manually.
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			reloader.maybeReload()
+			return verifyServerCertificate(state, reloader.caPool())
+		}
+	}
+
+	return config, nil
+}
+
+func verifyServerCertificate(state tls.ConnectionState, roots *x509.CertPool) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("server certificate is missing")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       state.ServerName,
+	})
+	if err != nil {
+		return fmt.Errorf("cert.Verify: %w", err)
+	}
+
+	return nil
+}
+
+type certReloader struct {
+	config TLSConfig
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	roots     *x509.CertPool
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func newCertReloader(config TLSConfig) (*certReloader, error) {
+	if config.ReloadInterval == 0 {
+		config.ReloadInterval = defaultCertReloadInterval
+	}
+
+	r := &certReloader{
+		config: config,
+	}
+
+	modTime, err := r.lastModTime()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.load(modTime); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *certReloader) certificate() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert
+}
+
+func (r *certReloader) caPool() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.roots
+}
+
+// maybeReload reloads files if they were changed, on failure the previous certificates are kept.
+func (r *certReloader) maybeReload() {
+	r.mu.RLock()
+	skip := time.Since(r.checkedAt) < r.config.ReloadInterval
+	r.mu.RUnlock()
+
+	if skip {
+		return
+	}
+
+	modTime, err := r.lastModTime()
+
+	r.mu.Lock()
+	r.checkedAt = time.Now()
+	changed := modTime.After(r.modTime)
+	r.mu.Unlock()
+
+	if err != nil {
+		logger.Errorf("certReloader.lastModTime: %v", err)
+		return
+	}
+
+	if !changed {
+		return
+	}
+
+	if err = r.load(modTime); err != nil {
+		logger.Errorf("certReloader.load: %v", err)
+		return
+	}
+
+	logger.Info("tls certificates are reloaded")
+}
+
+func (r *certReloader) load(modTime time.Time) error {
+	var cert *tls.Certificate
+	if r.config.CertFile != "" {
+		keyPair, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+		if err != nil {
+			return fmt.Errorf("tls.LoadX509KeyPair: %w", err)
+		}
+
+		cert = &keyPair
+	}
+
+	var roots *x509.CertPool
+	if r.config.CAFile != "" {
+		caPEM, err := os.ReadFile(r.config.CAFile)
+		if err != nil {
+			return fmt.Errorf("os.ReadFile: %w", err)
+		}
+
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(caPEM) {
+			return fmt.Errorf("no certificates found in %s", r.config.CAFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert = cert
+	r.roots = roots
+	r.modTime = modTime
+	r.checkedAt = time.Now()
+
+	return nil
+}
+
+func (r *certReloader) lastModTime() (time.Time, error) {
+	var modTime time.Time
+
+	for _, file := range []string{r.config.CertFile, r.config.KeyFile, r.config.CAFile} {
+		if file == "" {
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("os.Stat: %w", err)
+		}
+
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+
+	return modTime, nil
+}
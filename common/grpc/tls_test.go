@@ -0,0 +1,327 @@
+package grpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+var serial int64
+
+func newTestCert(t *testing.T, commonName string, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.DNSNames = []string{commonName}
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCert{cert: cert, key: key}
+}
+
+// write saves the certificate and its key, modification time is moved forward,
+// so a rewritten file is seen as changed regardless of file system time precision.
+func (c *testCert) write(t *testing.T, certFile, keyFile string, modTime time.Time) {
+	t.Helper()
+
+	writePEM(t, certFile, "CERTIFICATE", c.cert.Raw, modTime)
+
+	if keyFile != "" {
+		der, err := x509.MarshalECPrivateKey(c.key)
+		require.NoError(t, err)
+
+		writePEM(t, keyFile, "EC PRIVATE KEY", der, modTime)
+	}
+}
+
+func writePEM(t *testing.T, file, blockType string, der []byte, modTime time.Time) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	require.NoError(t, os.Chtimes(file, modTime, modTime))
+}
+
+// handshake connects the client to the server, returns handshake errors of both sides.
+func handshake(t *testing.T, serverConfig, clientConfig *tls.Config) (serverState tls.ConnectionState, clientErr, serverErr error) {
+	t.Helper()
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	server := tls.Server(serverConn, serverConfig)
+	client := tls.Client(clientConn, clientConfig)
+
+	serverDone := make(chan error, 1)
+	go func() {
+		err := server.Handshake()
+		if err != nil {
+			// unblock the client waiting for the server
+			serverConn.Close()
+		}
+		serverDone <- err
+	}()
+
+	clientErr = client.Handshake()
+	if clientErr != nil {
+		clientConn.Close()
+	} else {
+		// with TLS 1.3 the server verifies the client certificate after the client is done,
+		// its alert must be read
+		go func() { _, _ = io.Copy(io.Discard, client) }()
+	}
+
+	serverErr = <-serverDone
+
+	return server.ConnectionState(), clientErr, serverErr
+}
+
+type tlsFiles struct {
+	dir string
+
+	ca         *testCert
+	caFile     string
+	serverCert string
+	serverKey  string
+	clientCert string
+	clientKey  string
+}
+
+func newTLSFiles(t *testing.T) *tlsFiles {
+	t.Helper()
+
+	dir := t.TempDir()
+	f := &tlsFiles{
+		dir:        dir,
+		ca:         newTestCert(t, "ca", nil),
+		caFile:     filepath.Join(dir, "ca.pem"),
+		serverCert: filepath.Join(dir, "server.pem"),
+		serverKey:  filepath.Join(dir, "server-key.pem"),
+		clientCert: filepath.Join(dir, "client.pem"),
+		clientKey:  filepath.Join(dir, "client-key.pem"),
+	}
+
+	now := time.Now()
+	f.ca.write(t, f.caFile, "", now)
+	newTestCert(t, "users-service", f.ca).write(t, f.serverCert, f.serverKey, now)
+	newTestCert(t, "orders-service", f.ca).write(t, f.clientCert, f.clientKey, now)
+
+	return f
+}
+
+func TestTLS_ServerVerification(t *testing.T) {
+	files := newTLSFiles(t)
+
+	untrustedCAFile := filepath.Join(files.dir, "untrusted-ca.pem")
+	newTestCert(t, "untrusted-ca", nil).write(t, untrustedCAFile, "", time.Now())
+
+	tests := []struct {
+		name          string
+		clientConfig  TLSConfig
+		wantClientErr bool
+	}{
+		{
+			name:         "trusted server",
+			clientConfig: TLSConfig{CAFile: files.caFile, ServerName: "users-service"},
+		},
+		{
+			name:          "untrusted ca",
+			clientConfig:  TLSConfig{CAFile: untrustedCAFile, ServerName: "users-service"},
+			wantClientErr: true,
+		},
+		{
+			name:          "hostname mismatch",
+			clientConfig:  TLSConfig{CAFile: files.caFile, ServerName: "orders-service"},
+			wantClientErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverConfig, err := TLSConfig{CertFile: files.serverCert, KeyFile: files.serverKey}.serverConfig()
+			require.NoError(t, err)
+
+			clientConfig, err := tt.clientConfig.clientConfig()
+			require.NoError(t, err)
+
+			_, clientErr, _ := handshake(t, serverConfig, clientConfig)
+			if tt.wantClientErr {
+				require.Error(t, clientErr)
+				return
+			}
+
+			require.NoError(t, clientErr)
+		})
+	}
+}
+
+func TestTLS_ClientVerification(t *testing.T) {
+	files := newTLSFiles(t)
+
+	tests := []struct {
+		name              string
+		requireClientCert bool
+		clientConfig      TLSConfig
+		wantServerErr     bool
+		wantIdentity      string
+	}{
+		{
+			name:              "client certificate",
+			requireClientCert: true,
+			clientConfig:      TLSConfig{CertFile: files.clientCert, KeyFile: files.clientKey, CAFile: files.caFile, ServerName: "users-service"},
+			wantIdentity:      "orders-service",
+		},
+		{
+			name:              "mutual tls rejects client without certificate",
+			requireClientCert: true,
+			clientConfig:      TLSConfig{CAFile: files.caFile, ServerName: "users-service"},
+			wantServerErr:     true,
+		},
+		{
+			name:         "client certificate is optional",
+			clientConfig: TLSConfig{CAFile: files.caFile, ServerName: "users-service"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverConfig, err := TLSConfig{
+				CertFile:          files.serverCert,
+				KeyFile:           files.serverKey,
+				CAFile:            files.caFile,
+				RequireClientCert: tt.requireClientCert,
+			}.serverConfig()
+			require.NoError(t, err)
+
+			clientConfig, err := tt.clientConfig.clientConfig()
+			require.NoError(t, err)
+
+			state, _, serverErr := handshake(t, serverConfig, clientConfig)
+			if tt.wantServerErr {
+				require.Error(t, serverErr)
+				return
+			}
+
+			require.NoError(t, serverErr)
+
+			identity := ""
+			if len(state.VerifiedChains) > 0 {
+				identity = state.VerifiedChains[0][0].Subject.CommonName
+			}
+			require.Equal(t, tt.wantIdentity, identity)
+		})
+	}
+}
+
+func TestCertReloader_MaybeReload(t *testing.T) {
+	files := newTLSFiles(t)
+
+	reloader, err := newCertReloader(TLSConfig{
+		CertFile:       files.serverCert,
+		KeyFile:        files.serverKey,
+		CAFile:         files.caFile,
+		ReloadInterval: time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	initial := reloader.certificate()
+
+	t.Run("unchanged files are kept", func(t *testing.T) {
+		time.Sleep(2 * time.Millisecond)
+		reloader.maybeReload()
+
+		require.Same(t, initial, reloader.certificate())
+	})
+
+	t.Run("rotated certificate is picked up", func(t *testing.T) {
+		rotated := newTestCert(t, "users-service", files.ca)
+		rotated.write(t, files.serverCert, files.serverKey, time.Now().Add(time.Minute))
+
+		time.Sleep(2 * time.Millisecond)
+		reloader.maybeReload()
+
+		require.Equal(t, rotated.cert.Raw, reloader.certificate().Certificate[0])
+	})
+
+	t.Run("invalid files keep the previous certificate", func(t *testing.T) {
+		previous := reloader.certificate()
+
+		modTime := time.Now().Add(2 * time.Minute)
+		require.NoError(t, os.WriteFile(files.serverKey, []byte("invalid"), 0o600))
+		require.NoError(t, os.Chtimes(files.serverKey, modTime, modTime))
+
+		time.Sleep(2 * time.Millisecond)
+		reloader.maybeReload()
+
+		require.Same(t, previous, reloader.certificate())
+	})
+}
+
+func TestTLS_RotatedCA(t *testing.T) {
+	files := newTLSFiles(t)
+
+	serverConfig, err := TLSConfig{CertFile: files.serverCert, KeyFile: files.serverKey, ReloadInterval: time.Millisecond}.serverConfig()
+	require.NoError(t, err)
+
+	clientConfig, err := TLSConfig{CAFile: files.caFile, ServerName: "users-service", ReloadInterval: time.Millisecond}.clientConfig()
+	require.NoError(t, err)
+
+	_, clientErr, _ := handshake(t, serverConfig, clientConfig)
+	require.NoError(t, clientErr)
+
+	// the server certificate is issued by a new CA, clients trust it once the CA file is rotated too
+	newCA := newTestCert(t, "new-ca", nil)
+	newTestCert(t, "users-service", newCA).write(t, files.serverCert, files.serverKey, time.Now().Add(time.Minute))
+	time.Sleep(2 * time.Millisecond)
+
+	_, clientErr, _ = handshake(t, serverConfig, clientConfig)
+	require.Error(t, clientErr)
+
+	newCA.write(t, files.caFile, "", time.Now().Add(time.Minute))
+	time.Sleep(2 * time.Millisecond)
+
+	_, clientErr, _ = handshake(t, serverConfig, clientConfig)
+	require.NoError(t, clientErr)
+}
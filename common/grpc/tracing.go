@@ -3,52 +3,172 @@ package grpc
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
 	"github.com/fidesy/sdk/common/logger"
-	"github.com/opentracing/opentracing-go/ext"
-	"github.com/uber/jaeger-client-go"
+	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelbridge "go.opentelemetry.io/otel/bridge/opentracing"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
-	"io"
-	"time"
+)
 
-	"github.com/opentracing/opentracing-go"
-	jaegercfg "github.com/uber/jaeger-client-go/config"
+const (
+	tracerName = "github.com/fidesy/sdk/common/grpc"
+
+	OTLPProtocolGRPC = "grpc"
+	OTLPProtocolHTTP = "http"
 )
 
 var (
-	tracer opentracing.Tracer
-	closer io.Closer
+	// tracer is read on every client call, clients may be created before the tracer
+	tracer         atomic.Pointer[trace.Tracer]
+	tracerProvider *sdktrace.TracerProvider
+	bridgeTracer   *otelbridge.BridgeTracer
+	closer         io.Closer
 )
 
-func NewTracer(jaegerEndpoint string) (opentracing.Tracer, io.Closer, error) {
-	cfg := jaegercfg.Configuration{
-		Sampler: &jaegercfg.SamplerConfig{
-			Type:  "const",
-			Param: 1,
-		},
-		Reporter: &jaegercfg.ReporterConfig{
-			LogSpans:            true,
-			BufferFlushInterval: 1 * time.Second,
-			CollectorEndpoint:   jaegerEndpoint,
-		},
-		ServiceName: appName,
-	}
-
-	var (
-		closer io.Closer
-		err    error
-	)
+type (
+	TracerOption func(c *tracerConfig)
+	tracerConfig struct {
+		protocol string
+		secure   bool
+		sampler  sdktrace.Sampler
+	}
+
+	tracerCloser struct {
+		provider *sdktrace.TracerProvider
+	}
+)
+
+// WithOTLPProtocol sets OTLP exporter protocol, OTLPProtocolGRPC (default) or OTLPProtocolHTTP.
+func WithOTLPProtocol(protocol string) TracerOption {
+	return func(c *tracerConfig) {
+		c.protocol = protocol
+	}
+}
+
+// WithOTLPSecure exports spans over TLS, plaintext is used by default.
+func WithOTLPSecure() TracerOption {
+	return func(c *tracerConfig) {
+		c.secure = true
+	}
+}
+
+// WithSampleRatio samples the ratio of new traces and follows the parent decision for continued ones.
+func WithSampleRatio(ratio float64) TracerOption {
+	return func(c *tracerConfig) {
+		c.sampler = sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+	}
+}
+
+// WithSampler sets a custom sampler, by default every trace is sampled unless the parent is not sampled.
+func WithSampler(sampler sdktrace.Sampler) TracerOption {
+	return func(c *tracerConfig) {
+		c.sampler = sampler
+	}
+}
 
-	tracer, closer, err = cfg.NewTracer()
+// NewTracer creates OpenTelemetry tracer provider which exports spans to the OTLP endpoint and registers it globally.
+// The returned OpenTracing bridge is registered as opentracing global tracer, so services
+// still using opentracing-go produce spans in the same traces. Closer flushes the remaining spans.
+func NewTracer(endpoint string, options ...TracerOption) (opentracing.Tracer, io.Closer, error) {
+	config := &tracerConfig{
+		protocol: OTLPProtocolGRPC,
+		sampler:  sdktrace.ParentBased(sdktrace.AlwaysSample()),
+	}
+
+	for _, opt := range options {
+		opt(config)
+	}
+
+	exporter, err := newExporter(endpoint, config)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, fmt.Errorf("newExporter: %w", err)
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(appName),
+			semconv.DeploymentEnvironment(strings.ToLower(os.Getenv("ENV"))),
+		),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("resource.Merge: %w", err)
+	}
+
+	tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(config.sampler),
+	)
+
+	var wrapperProvider *otelbridge.WrapperTracerProvider
+	bridgeTracer, wrapperProvider = otelbridge.NewTracerPair(tracerProvider.Tracer(tracerName))
+
+	bridgeTracer.SetTextMapPropagator(propagator)
+
+	otel.SetTracerProvider(wrapperProvider)
+	otel.SetTextMapPropagator(propagator)
+	opentracing.SetGlobalTracer(bridgeTracer)
+
+	otelTracer := otel.Tracer(tracerName)
+	tracer.Store(&otelTracer)
+	closer = &tracerCloser{provider: tracerProvider}
+
+	return bridgeTracer, closer, nil
+}
+
+func newExporter(endpoint string, config *tracerConfig) (*otlptrace.Exporter, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	switch config.protocol {
+	case OTLPProtocolGRPC:
+		options := []otlptracegrpc.Option{
+			otlptracegrpc.WithEndpoint(endpoint),
+		}
+		if !config.secure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+
+		return otlptracegrpc.New(ctx, options...)
+	case OTLPProtocolHTTP:
+		options := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(endpoint),
+		}
+		if !config.secure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+
+		return otlptracehttp.New(ctx, options...)
+	default:
+		return nil, fmt.Errorf("unknown OTLP protocol %q", config.protocol)
 	}
+}
 
-	opentracing.SetGlobalTracer(tracer)
+func (c *tracerCloser) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	return tracer, closer, nil
+	return c.provider.Shutdown(ctx)
 }
 
 func tracingInterceptor() grpc.UnaryServerInterceptor {
@@ -58,61 +178,143 @@ func tracingInterceptor() grpc.UnaryServerInterceptor {
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			md = metadata.New(nil)
-		}
-
-		traceIDStr := ""
-
-		// trace id is present in metadata
-		if len(md.Get("x-trace-id")) > 0 {
-			spanID, _ := jaeger.SpanIDFromString(md.Get("x-span-id")[0])
-			traceID, _ := jaeger.TraceIDFromString(md.Get("x-trace-id")[0])
-			traceIDStr = traceID.String()
-
-			parentSpanCtx := jaeger.NewSpanContext(traceID, spanID, jaeger.SpanID(0), false, nil)
-			span := tracer.StartSpan(
-				info.FullMethod,
-				ext.RPCServerOption(parentSpanCtx),
-			)
-			defer span.Finish()
-
-			jaegerSpan, _ := span.(*jaeger.Span)
-			ctx = metadata.AppendToOutgoingContext(ctx, "x-span-id", jaegerSpan.SpanContext().SpanID().String())
-			ctx = metadata.AppendToOutgoingContext(ctx, "x-trace-id", traceID.String())
-		} else {
-			span := tracer.StartSpan(info.FullMethod)
-			defer span.Finish()
-
-			jaegerSpan, _ := span.(*jaeger.Span)
-			ctx = metadata.AppendToOutgoingContext(ctx, "x-trace-id", fmt.Sprint(jaegerSpan.SpanContext().TraceID()))
-			ctx = metadata.AppendToOutgoingContext(ctx, "x-span-id", fmt.Sprint(jaegerSpan.SpanContext().TraceID()))
-			traceIDStr = jaegerSpan.SpanContext().TraceID().String()
-		}
+		ctx, span, traceIDStr := startServerSpan(ctx, info.FullMethod)
+		defer span.End()
 
 		// Call the gRPC handler.
 		response, handlerErr := handler(ctx, req)
 
-		if handlerErr == nil {
-			return response, nil
-		}
+		setSpanStatus(span, handlerErr)
+		logHandlerError(handlerErr, traceIDStr)
 
-		st, ok := status.FromError(handlerErr)
-		if !ok {
-			return response, handlerErr
-		}
-		// log error
-		logger.Errorf(
-			"%w", handlerErr,
-			zap.String("trace_id", traceIDStr),
-			zap.String("status_code", st.Code().String()),
+		return response, handlerErr
+	}
+}
+
+func streamTracingInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, span, traceIDStr := startServerSpan(stream.Context(), info.FullMethod)
+		defer span.End()
+
+		span.SetAttributes(
+			attribute.Bool("rpc.grpc.client_stream", info.IsClientStream),
+			attribute.Bool("rpc.grpc.server_stream", info.IsServerStream),
 		)
 
-		return response, handlerErr
+		handlerErr := handler(srv, wrapServerStream(stream, ctx))
+
+		setSpanStatus(span, handlerErr)
+		logHandlerError(handlerErr, traceIDStr)
+
+		return handlerErr
 	}
 }
 
+// loadTracer returns the tracer created by NewTracer or nil.
+func loadTracer() trace.Tracer {
+	if t := tracer.Load(); t != nil {
+		return *t
+	}
+
+	return nil
+}
+
+// startServerSpan starts a span continuing the trace from incoming metadata
+// and propagates it to outgoing calls.
+func startServerSpan(ctx context.Context, fullMethod string) (context.Context, trace.Span, string) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		md = metadata.New(nil)
+	}
+
+	ctx = extractTraceContext(ctx, md)
+
+	// spans started with opentracing-go in handlers become children of the server span
+	if bridgeTracer != nil {
+		ctx = bridgeTracer.NewHookedContext(ctx)
+	}
+
+	service, method := splitFullMethod(fullMethod)
+
+	ctx, span := loadTracer().Start(
+		ctx,
+		fullMethod,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.RPCSystemGRPC,
+			semconv.RPCService(service),
+			semconv.RPCMethod(method),
+		),
+	)
+
+	// outgoing calls continue the trace even without client tracing
+	ctx = injectOutgoingTraceContext(ctx)
+
+	return ctx, span, span.SpanContext().TraceID().String()
+}
+
+func splitFullMethod(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "", fullMethod
+	}
+
+	return service, method
+}
+
+func setSpanStatus(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(otelcodes.Error, err.Error())
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(status.Code(err))))
+}
+
+// traceIDFromContext returns the trace id of the current span, if any.
+func traceIDFromContext(ctx context.Context) string {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.HasTraceID() {
+		return ""
+	}
+
+	return spanCtx.TraceID().String()
+}
+
+func logHandlerError(handlerErr error, traceIDStr string) {
+	if handlerErr == nil {
+		return
+	}
+
+	st, ok := status.FromError(handlerErr)
+	if !ok {
+		return
+	}
+	// log error
+	logger.Errorf(
+		"%w", handlerErr,
+		zap.String("trace_id", traceIDStr),
+		zap.String("status_code", st.Code().String()),
+	)
+}
+
+// GetTracer returns OpenTracing bridge of the tracer.
+//
+// Deprecated: use GetTracerProvider or otel.Tracer.
 func GetTracer() opentracing.Tracer {
-	return tracer
+	if bridgeTracer == nil {
+		return nil
+	}
+
+	return bridgeTracer
+}
+
+func GetTracerProvider() trace.TracerProvider {
+	return otel.GetTracerProvider()
 }
@@ -0,0 +1,155 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type (
+	// allValidator is implemented by protoc-gen-validate messages, returns every violation.
+	allValidator interface {
+		ValidateAll() error
+	}
+
+	validator interface {
+		Validate() error
+	}
+
+	// fieldError is implemented by protoc-gen-validate <Message>ValidationError.
+	fieldError interface {
+		Field() string
+		Reason() string
+	}
+
+	// causer is implemented by protoc-gen-validate errors of embedded messages.
+	causer interface {
+		Cause() error
+	}
+
+	// multiError is implemented by protoc-gen-validate <Message>MultiError.
+	multiError interface {
+		AllErrors() []error
+	}
+)
+
+// WithoutValidation disables validation of requests implementing Validate() or ValidateAll().
+func WithoutValidation() ServerOption {
+	return func(s *Server) error {
+		s.disableValidation = true
+		return nil
+	}
+}
+
+func validationInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if err := validate(req); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func streamValidationInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		return handler(srv, &validatingServerStream{ServerStream: stream})
+	}
+}
+
+// validatingServerStream validates every message received from the client.
+type validatingServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatingServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return validate(m)
+}
+
+// validate returns codes.InvalidArgument with google.rpc.BadRequest details,
+// the gateway responds with 400 and field violations in the body.
+func validate(req interface{}) error {
+	var err error
+	switch v := req.(type) {
+	case allValidator:
+		err = v.ValidateAll()
+	case validator:
+		err = v.Validate()
+	default:
+		return nil
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	violations := fieldViolations(err, "")
+	if len(violations) == 0 {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Description: err.Error(),
+		})
+	}
+
+	st, detailsErr := status.New(codes.InvalidArgument, err.Error()).WithDetails(&errdetails.BadRequest{
+		FieldViolations: violations,
+	})
+	if detailsErr != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return st.Err()
+}
+
+func fieldViolations(err error, prefix string) []*errdetails.BadRequest_FieldViolation {
+	var multi multiError
+	if errors.As(err, &multi) {
+		var violations []*errdetails.BadRequest_FieldViolation
+		for _, e := range multi.AllErrors() {
+			violations = append(violations, fieldViolations(e, prefix)...)
+		}
+
+		return violations
+	}
+
+	var fe fieldError
+	if !errors.As(err, &fe) {
+		return nil
+	}
+
+	field := fe.Field()
+	if prefix != "" {
+		field = prefix + "." + field
+	}
+
+	// violation is inside of embedded message
+	if c, ok := fe.(causer); ok && c.Cause() != nil {
+		if nested := fieldViolations(c.Cause(), field); len(nested) > 0 {
+			return nested
+		}
+	}
+
+	return []*errdetails.BadRequest_FieldViolation{
+		{
+			Field:       field,
+			Description: fe.Reason(),
+		},
+	}
+}
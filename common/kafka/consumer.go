@@ -10,6 +10,9 @@ import (
 
 	"github.com/fidesy/sdk/common/logger"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type (
@@ -76,16 +79,23 @@ func (c *Consumer) Consume(ctx context.Context) error {
 		// Waiting until previous message is done
 		c.locks[m.Partition].Lock()
 
-		err = c.messageHandler.ProcessMessage(ctx, m.Value)
+		// Continue the trace of the producer
+		msgCtx := otel.GetTextMapPropagator().Extract(ctx, headersCarrier{headers: &m.Headers})
+		msgCtx, span := startSpan(msgCtx, "kafka.ProcessMessage", trace.SpanKindConsumer, m.Topic)
+
+		err = c.messageHandler.ProcessMessage(msgCtx, m.Value)
 		if err != nil {
 			logger.Info(fmt.Sprintf("error while consuming message: %v", err))
-			err = c.consumeWithRetries(ctx, m)()
+			err = c.consumeWithRetries(msgCtx, m)()
 			if err != nil {
+				span.SetStatus(codes.Error, err.Error())
 				logger.Errorf("consumeWithRetries: %v", err)
 				// Must send to DLQ
 			}
 		}
 
+		span.End()
+
 		err = c.reader.CommitMessages(ctx, m)
 		if err != nil {
 			logger.Errorf("reader.CommitMessages: %v", err)
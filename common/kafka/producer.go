@@ -5,6 +5,9 @@ import (
 	"fmt"
 
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Producer struct {
@@ -19,16 +22,24 @@ func NewProducer(
 	}
 }
 
+// ProduceMessage writes messages with W3C trace context and baggage in headers.
 func (p *Producer) ProduceMessage(ctx context.Context, messages [][]byte) error {
+	ctx, span := startSpan(ctx, "kafka.ProduceMessage", trace.SpanKindProducer, p.writer.Topic)
+	defer span.End()
+
 	kafkaMessages := make([]kafka.Message, 0, len(messages))
 	for _, msg := range messages {
-		kafkaMessages = append(kafkaMessages, kafka.Message{
+		message := kafka.Message{
 			Value: msg,
-		})
+		}
+		otel.GetTextMapPropagator().Inject(ctx, headersCarrier{headers: &message.Headers})
+
+		kafkaMessages = append(kafkaMessages, message)
 	}
 
 	err := p.writer.WriteMessages(ctx, kafkaMessages...)
 	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
 		return fmt.Errorf("writer.WriteMessages: %w", err)
 	}
 
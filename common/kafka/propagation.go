@@ -0,0 +1,63 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/fidesy/sdk/common/kafka"
+
+// headersCarrier adapts kafka message headers to propagation.TextMapCarrier.
+type headersCarrier struct {
+	headers *[]kafka.Header
+}
+
+func (c headersCarrier) Get(key string) string {
+	for _, header := range *c.headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+
+	return ""
+}
+
+func (c headersCarrier) Set(key, value string) {
+	for i, header := range *c.headers {
+		if header.Key == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+
+	*c.headers = append(*c.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c headersCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c.headers))
+	for _, header := range *c.headers {
+		keys = append(keys, header.Key)
+	}
+
+	return keys
+}
+
+var _ propagation.TextMapCarrier = headersCarrier{}
+
+// startSpan starts a span of kafka operation, global tracer provider and propagator are set by grpc.NewTracer.
+func startSpan(ctx context.Context, name string, kind trace.SpanKind, topic string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(
+		ctx,
+		name,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(topic),
+		),
+	)
+}
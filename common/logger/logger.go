@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -12,30 +13,43 @@ import (
 )
 
 var (
-	l       *zap.Logger
+	l       atomic.Pointer[zap.Logger]
 	once    sync.Once
-	appName string
+	appName = os.Getenv("APP_NAME")
+
+	// fallback is used until Init, e.g. by resolvers of clients created before the server
+	fallback = newLogger(os.Stderr)
 )
 
 func Init(w io.Writer) {
 	once.Do(func() {
-		appName = os.Getenv("APP_NAME")
+		l.Store(newLogger(w))
+	})
+}
 
-		config := zapcore.EncoderConfig{}
-		config.EncodeTime = zapcore.ISO8601TimeEncoder
+func newLogger(w io.Writer) *zap.Logger {
+	config := zapcore.EncoderConfig{}
+	config.EncodeTime = zapcore.ISO8601TimeEncoder
 
-		core := zapcore.NewCore(
-			zapcore.NewJSONEncoder(config),
-			zapcore.AddSync(w),
-			zapcore.InfoLevel,
-		)
+	core := zapcore.NewCore(
+		zapcore.NewJSONEncoder(config),
+		zapcore.AddSync(w),
+		zapcore.InfoLevel,
+	)
 
-		l = zap.New(core)
-	})
+	return zap.New(core)
 }
 
 func Get() *zap.Logger {
-	return l
+	return l.Load()
+}
+
+func get() *zap.Logger {
+	if logger := l.Load(); logger != nil {
+		return logger
+	}
+
+	return fallback
 }
 
 func Info(msg string, fields ...zap.Field) {
@@ -45,7 +59,7 @@ func Info(msg string, fields ...zap.Field) {
 		zap.Time("timestamp", time.Now()),
 	}, fields...)
 
-	l.Info("info", fields...)
+	get().Info("info", fields...)
 }
 
 func Errorf(format string, err error, fields ...zap.Field) {
@@ -55,11 +69,11 @@ func Errorf(format string, err error, fields ...zap.Field) {
 		zap.Time("timestamp", time.Now()),
 	}, fields...)
 
-	l.Error("error", fields...)
+	get().Error("error", fields...)
 }
 
 func Fatalf(format string, a ...any) {
-	l.Fatal("fatal", zap.Error(
+	get().Fatal("fatal", zap.Error(
 		fmt.Errorf(format, a...),
 	),
 		zap.Time("timestamp", time.Now()),
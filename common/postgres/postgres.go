@@ -12,9 +12,13 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/fidesy/sdk/common/postgres")
+
 func Connect(ctx context.Context, pgDsn string) (*pgxpool.Pool, error) {
 	pool, err := pgxpool.New(ctx, pgDsn)
 	if err != nil {
@@ -33,12 +37,11 @@ func Builder() sq.StatementBuilderType {
 }
 
 func Exec[T any](ctx context.Context, db pgxscan.Querier, sqlizer sq.Sqlizer) (*T, error) {
-	var span opentracing.Span
-
 	skipSpan, ok := ctx.Value("skip_span").(bool)
 	if !ok || !skipSpan {
-		span, ctx = opentracing.StartSpanFromContext(ctx, "postgres.Exec")
-		defer span.Finish()
+		var span trace.Span
+		ctx, span = startSpan(ctx, "postgres.Exec")
+		defer span.End()
 	}
 
 	query, args, err := sqlizer.ToSql()
@@ -57,12 +60,11 @@ func Exec[T any](ctx context.Context, db pgxscan.Querier, sqlizer sq.Sqlizer) (*
 }
 
 func ExecWithOutbox(ctx context.Context, db *pgxpool.Pool, dst Model, sqlizer sq.Sqlizer) error {
-	var span opentracing.Span
-
 	skipSpan, ok := ctx.Value("skip_span").(bool)
 	if !ok || !skipSpan {
-		span, ctx = opentracing.StartSpanFromContext(ctx, "postgres.Exec")
-		defer span.Finish()
+		var span trace.Span
+		ctx, span = startSpan(ctx, "postgres.Exec")
+		defer span.End()
 	}
 
 	query, args, err := sqlizer.ToSql()
@@ -107,12 +109,11 @@ func ExecWithOutbox(ctx context.Context, db *pgxpool.Pool, dst Model, sqlizer sq
 }
 
 func ExecWithOutboxTx(ctx context.Context, tx pgx.Tx, dst Model, sqlizer sq.Sqlizer) error {
-	var span opentracing.Span
-
 	skipSpan, ok := ctx.Value("skip_span").(bool)
 	if !ok || !skipSpan {
-		span, ctx = opentracing.StartSpanFromContext(ctx, "postgres.ExecWithOutboxTx")
-		defer span.Finish()
+		var span trace.Span
+		ctx, span = startSpan(ctx, "postgres.ExecWithOutboxTx")
+		defer span.End()
 	}
 
 	query, args, err := sqlizer.ToSql()
@@ -150,12 +151,11 @@ func ExecWithOutboxTx(ctx context.Context, tx pgx.Tx, dst Model, sqlizer sq.Sqli
 }
 
 func Select[T any](ctx context.Context, db pgxscan.Querier, sqlizer sq.Sqlizer) ([]*T, error) {
-	var span opentracing.Span
-
 	skipSpan, ok := ctx.Value("skip_span").(bool)
 	if !ok || !skipSpan {
-		span, ctx = opentracing.StartSpanFromContext(ctx, "postgres.Select")
-		defer span.Finish()
+		var span trace.Span
+		ctx, span = startSpan(ctx, "postgres.Select")
+		defer span.End()
 	}
 
 	query, args, err := sqlizer.ToSql()
@@ -191,6 +191,15 @@ func WithTransaction(ctx context.Context, pool *pgxpool.Pool, callback func(tx p
 	return tx.Commit(ctx)
 }
 
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(
+		ctx,
+		name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL),
+	)
+}
+
 func handleError(err error) error {
 	if err == nil {
 		return nil
@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/opentracing/opentracing-go"
-
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/fidesy/sdk/common/redis")
+
 type Client struct {
 	redisClient *redis.Client
 }
@@ -30,8 +33,13 @@ func (c *Client) RedisClient() *redis.Client {
 }
 
 func (c *Client) Set(ctx context.Context, key string, val interface{}, expiration time.Duration) error {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "redis.Set")
-	defer span.Finish()
+	ctx, span := tracer.Start(
+		ctx,
+		"redis.Set",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis),
+	)
+	defer span.End()
 
 	bytes, err := json.Marshal(val)
 	if err != nil {
@@ -47,8 +55,13 @@ func (c *Client) Set(ctx context.Context, key string, val interface{}, expiratio
 }
 
 func (c *Client) Get(ctx context.Context, key string, dst interface{}) (bool, error) {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "redis.Get")
-	defer span.Finish()
+	ctx, span := tracer.Start(
+		ctx,
+		"redis.Get",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis),
+	)
+	defer span.End()
 
 	result := c.redisClient.Get(ctx, key)
 	if err := result.Err(); err != nil {
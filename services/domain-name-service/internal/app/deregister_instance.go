@@ -0,0 +1,17 @@
+package app
+
+import (
+	"context"
+	desc "github.com/fidesy/sdk/services/domain-name-service/pkg/domain-name-service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (i *Implementation) DeregisterInstance(ctx context.Context, req *desc.DeregisterInstanceRequest) (*desc.DeregisterInstanceResponse, error) {
+	err := i.domainNameService.DeregisterInstance(ctx, req.GetServiceName(), req.GetAddress())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "domainNameService.DeregisterInstance: %v", err)
+	}
+
+	return &desc.DeregisterInstanceResponse{}, nil
+}
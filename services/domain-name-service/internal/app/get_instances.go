@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+
+	domain_name_service "github.com/fidesy/sdk/services/domain-name-service/internal/pkg/domain-name-service"
+	desc "github.com/fidesy/sdk/services/domain-name-service/pkg/domain-name-service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (i *Implementation) GetInstances(ctx context.Context, req *desc.GetInstancesRequest) (*desc.GetInstancesResponse, error) {
+	instances, err := i.domainNameService.GetInstances(ctx, req.GetServiceName())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "domainNameService.GetInstances: %v", err)
+	}
+
+	return &desc.GetInstancesResponse{
+		Instances: instancesToDesc(instances),
+	}, nil
+}
+
+func instancesToDesc(instances []domain_name_service.Instance) []*desc.Instance {
+	result := make([]*desc.Instance, 0, len(instances))
+	for _, instance := range instances {
+		result = append(result, &desc.Instance{
+			Address: instance.Address,
+			Weight:  instance.Weight,
+			Zone:    instance.Zone,
+			Version: instance.Version,
+		})
+	}
+
+	return result
+}
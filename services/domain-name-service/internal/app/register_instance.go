@@ -0,0 +1,29 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	domain_name_service "github.com/fidesy/sdk/services/domain-name-service/internal/pkg/domain-name-service"
+	desc "github.com/fidesy/sdk/services/domain-name-service/pkg/domain-name-service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (i *Implementation) RegisterInstance(ctx context.Context, req *desc.RegisterInstanceRequest) (*desc.RegisterInstanceResponse, error) {
+	if req.GetInstance().GetAddress() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "instance address is required")
+	}
+
+	err := i.domainNameService.RegisterInstance(ctx, req.GetServiceName(), domain_name_service.Instance{
+		Address: req.GetInstance().GetAddress(),
+		Weight:  req.GetInstance().GetWeight(),
+		Zone:    req.GetInstance().GetZone(),
+		Version: req.GetInstance().GetVersion(),
+	}, time.Duration(req.GetTtlSeconds())*time.Second)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "domainNameService.RegisterInstance: %v", err)
+	}
+
+	return &desc.RegisterInstanceResponse{}, nil
+}
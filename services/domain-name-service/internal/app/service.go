@@ -2,6 +2,9 @@ package app
 
 import (
 	"context"
+	"time"
+
+	domain_name_service "github.com/fidesy/sdk/services/domain-name-service/internal/pkg/domain-name-service"
 	desc "github.com/fidesy/sdk/services/domain-name-service/pkg/domain-name-service"
 )
 
@@ -15,6 +18,10 @@ type (
 	DomainNameService interface {
 		GetAddress(ctx context.Context, serviceName string) (string, error)
 		UpdateAddress(ctx context.Context, serviceName string, address string) error
+		GetInstances(ctx context.Context, serviceName string) ([]domain_name_service.Instance, error)
+		RegisterInstance(ctx context.Context, serviceName string, instance domain_name_service.Instance, ttl time.Duration) error
+		DeregisterInstance(ctx context.Context, serviceName, address string) error
+		WatchInstances(ctx context.Context, serviceName string, send func(instances []domain_name_service.Instance) error) error
 	}
 )
 
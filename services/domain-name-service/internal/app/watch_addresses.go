@@ -0,0 +1,21 @@
+package app
+
+import (
+	domain_name_service "github.com/fidesy/sdk/services/domain-name-service/internal/pkg/domain-name-service"
+	desc "github.com/fidesy/sdk/services/domain-name-service/pkg/domain-name-service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (i *Implementation) WatchAddresses(req *desc.WatchAddressesRequest, stream desc.DomainNameService_WatchAddressesServer) error {
+	err := i.domainNameService.WatchInstances(stream.Context(), req.GetServiceName(), func(instances []domain_name_service.Instance) error {
+		return stream.Send(&desc.WatchAddressesResponse{
+			Instances: instancesToDesc(instances),
+		})
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "domainNameService.WatchInstances: %v", err)
+	}
+
+	return nil
+}
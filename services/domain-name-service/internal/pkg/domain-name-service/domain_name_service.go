@@ -2,26 +2,60 @@ package domain_name_service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 )
 
 type Storage interface {
 	Get(ctx context.Context, key string) ([]byte, error)
 	Set(ctx context.Context, key string, bytes []byte, expiration time.Duration) error
+	Delete(ctx context.Context, key string) error
+	CompareAndDelete(ctx context.Context, key string, expected []byte) error
+
+	SetField(ctx context.Context, key, field string, bytes []byte) error
+	GetFields(ctx context.Context, key string) (map[string][]byte, error)
+	DeleteField(ctx context.Context, key, field string) error
+
+	Publish(ctx context.Context, channel string) error
+	Subscribe(ctx context.Context, channel string) (<-chan struct{}, error)
 }
 
+// expirationCheckInterval is how often watchers check instances without a heartbeat.
+const expirationCheckInterval = 5 * time.Second
+
 type Service struct {
 	storage Storage
 }
 
+// Instance of a service, registered instances are kept in one hash per service.
+type Instance struct {
+	Address string `json:"address"`
+	Weight  uint32 `json:"weight"`
+	Zone    string `json:"zone,omitempty"`
+	Version string `json:"version,omitempty"`
+	// zero time means the instance never expires
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
 func New(storage Storage) *Service {
 	return &Service{
 		storage: storage,
 	}
 }
 
+// GetAddress returns the first healthy instance or the address set by UpdateAddress.
 func (s *Service) GetAddress(ctx context.Context, serviceName string) (string, error) {
+	instances, err := s.GetInstances(ctx, serviceName)
+	if err != nil {
+		return "", fmt.Errorf("GetInstances: %w", err)
+	}
+
+	if len(instances) > 0 {
+		return instances[0].Address, nil
+	}
+
 	bytes, err := s.storage.Get(ctx, serviceName)
 	if err != nil {
 		return "", fmt.Errorf("storage.Get: %w", err)
@@ -31,10 +65,184 @@ func (s *Service) GetAddress(ctx context.Context, serviceName string) (string, e
 }
 
 func (s *Service) UpdateAddress(ctx context.Context, serviceName string, address string) error {
+	// empty address deregisters the service
+	if address == "" {
+		err := s.storage.Delete(ctx, serviceName)
+		if err != nil {
+			return fmt.Errorf("storage.Delete: %w", err)
+		}
+
+		return s.notify(ctx, serviceName)
+	}
+
 	err := s.storage.Set(ctx, serviceName, []byte(address), 0)
 	if err != nil {
 		return fmt.Errorf("storage.Set: %w", err)
 	}
 
+	return s.notify(ctx, serviceName)
+}
+
+// GetInstances returns not expired instances sorted by address, expired ones are removed.
+func (s *Service) GetInstances(ctx context.Context, serviceName string) ([]Instance, error) {
+	fields, err := s.storage.GetFields(ctx, instancesKey(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("storage.GetFields: %w", err)
+	}
+
+	now := time.Now()
+	instances := make([]Instance, 0, len(fields))
+	for address, bytes := range fields {
+		var instance Instance
+		if err = json.Unmarshal(bytes, &instance); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal: %w", err)
+		}
+
+		if instance.expired(now) {
+			if err = s.storage.DeleteField(ctx, instancesKey(serviceName), address); err != nil {
+				return nil, fmt.Errorf("storage.DeleteField: %w", err)
+			}
+
+			continue
+		}
+
+		instances = append(instances, instance)
+	}
+
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].Address < instances[j].Address
+	})
+
+	return instances, nil
+}
+
+// RegisterInstance adds or refreshes the instance, zero ttl means it never expires.
+func (s *Service) RegisterInstance(ctx context.Context, serviceName string, instance Instance, ttl time.Duration) error {
+	if instance.Weight == 0 {
+		instance.Weight = 1
+	}
+
+	if ttl > 0 {
+		instance.ExpiresAt = time.Now().Add(ttl)
+	}
+
+	fields, err := s.storage.GetFields(ctx, instancesKey(serviceName))
+	if err != nil {
+		return fmt.Errorf("storage.GetFields: %w", err)
+	}
+
+	// heartbeats of a known instance only prolong its ttl, watchers are not notified
+	changed := true
+	if previousBytes, ok := fields[instance.Address]; ok {
+		var previous Instance
+		if err = json.Unmarshal(previousBytes, &previous); err == nil && !previous.expired(time.Now()) {
+			changed = !previous.equal(instance)
+		}
+	}
+
+	bytes, err := json.Marshal(instance)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	err = s.storage.SetField(ctx, instancesKey(serviceName), instance.Address, bytes)
+	if err != nil {
+		return fmt.Errorf("storage.SetField: %w", err)
+	}
+
+	if changed {
+		return s.notify(ctx, serviceName)
+	}
+
 	return nil
 }
+
+func (s *Service) DeregisterInstance(ctx context.Context, serviceName, address string) error {
+	err := s.storage.DeleteField(ctx, instancesKey(serviceName), address)
+	if err != nil {
+		return fmt.Errorf("storage.DeleteField: %w", err)
+	}
+
+	// the address could be set by UpdateAddress before instances were supported,
+	// it is shared by replicas, so it is cleared only if it is still this instance address
+	err = s.storage.CompareAndDelete(ctx, serviceName, []byte(address))
+	if err != nil {
+		return fmt.Errorf("storage.CompareAndDelete: %w", err)
+	}
+
+	return s.notify(ctx, serviceName)
+}
+
+// WatchInstances calls send with the current instances and then with every change of them until ctx is done.
+func (s *Service) WatchInstances(ctx context.Context, serviceName string, send func(instances []Instance) error) error {
+	changes, err := s.storage.Subscribe(ctx, instancesKey(serviceName))
+	if err != nil {
+		return fmt.Errorf("storage.Subscribe: %w", err)
+	}
+
+	// instances expire without a notification, so they are checked periodically
+	ticker := time.NewTicker(expirationCheckInterval)
+	defer ticker.Stop()
+
+	var last []Instance
+	for first := true; ; first = false {
+		instances, err := s.GetInstances(ctx, serviceName)
+		if err != nil {
+			return fmt.Errorf("GetInstances: %w", err)
+		}
+
+		if first || !equalInstances(instances, last) {
+			if err = send(instances); err != nil {
+				return fmt.Errorf("send: %w", err)
+			}
+
+			last = instances
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changes:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) notify(ctx context.Context, serviceName string) error {
+	err := s.storage.Publish(ctx, instancesKey(serviceName))
+	if err != nil {
+		return fmt.Errorf("storage.Publish: %w", err)
+	}
+
+	return nil
+}
+
+func (i Instance) expired(now time.Time) bool {
+	return !i.ExpiresAt.IsZero() && i.ExpiresAt.Before(now)
+}
+
+// equal compares instances ignoring expiration.
+func (i Instance) equal(other Instance) bool {
+	return i.Address == other.Address &&
+		i.Weight == other.Weight &&
+		i.Zone == other.Zone &&
+		i.Version == other.Version
+}
+
+func equalInstances(a, b []Instance) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !a[i].equal(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func instancesKey(serviceName string) string {
+	return "instances:" + serviceName
+}
@@ -13,6 +13,14 @@ var (
 	ErrNotFound = errors.New("record with provided serviceName not found")
 )
 
+// compareAndDelete deletes the key only if it has the expected value.
+var compareAndDelete = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
 type Service struct {
 	db *redis.Client
 }
@@ -63,6 +71,24 @@ func (s *Service) Get(ctx context.Context, key string) ([]byte, error) {
 	return bytes, nil
 }
 
+func (s *Service) Delete(ctx context.Context, key string) error {
+	err := s.db.Del(ctx, key).Err()
+	if err != nil {
+		return fmt.Errorf("redis.Del: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) CompareAndDelete(ctx context.Context, key string, expected []byte) error {
+	err := compareAndDelete.Run(ctx, s.db, []string{key}, expected).Err()
+	if err != nil {
+		return fmt.Errorf("redis.Eval: %w", err)
+	}
+
+	return nil
+}
+
 func (s *Service) Size(ctx context.Context) (int, error) {
 	size, err := s.db.DBSize(ctx).Result()
 	if err != nil {
@@ -71,3 +97,79 @@ func (s *Service) Size(ctx context.Context) (int, error) {
 
 	return int(size), nil
 }
+
+func (s *Service) SetField(ctx context.Context, key, field string, bytes []byte) error {
+	err := s.db.HSet(ctx, key, field, bytes).Err()
+	if err != nil {
+		return fmt.Errorf("redis.HSet: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetFields(ctx context.Context, key string) (map[string][]byte, error) {
+	result, err := s.db.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis.HGetAll: %w", err)
+	}
+
+	fields := make(map[string][]byte, len(result))
+	for field, value := range result {
+		fields[field] = []byte(value)
+	}
+
+	return fields, nil
+}
+
+func (s *Service) DeleteField(ctx context.Context, key, field string) error {
+	err := s.db.HDel(ctx, key, field).Err()
+	if err != nil {
+		return fmt.Errorf("redis.HDel: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) Publish(ctx context.Context, channel string) error {
+	err := s.db.Publish(ctx, channel, "").Err()
+	if err != nil {
+		return fmt.Errorf("redis.Publish: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe notifies about messages published to the channel until ctx is done,
+// notifications are merged while the previous one is not received.
+func (s *Service) Subscribe(ctx context.Context, channel string) (<-chan struct{}, error) {
+	pubsub := s.db.Subscribe(ctx, channel)
+	// wait for the subscription, so that messages published after it are not lost
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, fmt.Errorf("redis.Subscribe: %w", err)
+	}
+
+	notifications := make(chan struct{}, 1)
+	go func() {
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				select {
+				case notifications <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return notifications, nil
+}
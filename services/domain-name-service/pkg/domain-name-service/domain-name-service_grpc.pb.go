@@ -19,8 +19,12 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	DomainNameService_GetAddress_FullMethodName    = "/domain_name_service.DomainNameService/GetAddress"
-	DomainNameService_UpdateAddress_FullMethodName = "/domain_name_service.DomainNameService/UpdateAddress"
+	DomainNameService_GetAddress_FullMethodName         = "/domain_name_service.DomainNameService/GetAddress"
+	DomainNameService_UpdateAddress_FullMethodName      = "/domain_name_service.DomainNameService/UpdateAddress"
+	DomainNameService_GetInstances_FullMethodName       = "/domain_name_service.DomainNameService/GetInstances"
+	DomainNameService_RegisterInstance_FullMethodName   = "/domain_name_service.DomainNameService/RegisterInstance"
+	DomainNameService_DeregisterInstance_FullMethodName = "/domain_name_service.DomainNameService/DeregisterInstance"
+	DomainNameService_WatchAddresses_FullMethodName     = "/domain_name_service.DomainNameService/WatchAddresses"
 )
 
 // DomainNameServiceClient is the client API for DomainNameService service.
@@ -29,6 +33,13 @@ const (
 type DomainNameServiceClient interface {
 	GetAddress(ctx context.Context, in *GetAddressRequest, opts ...grpc.CallOption) (*GetAddressResponse, error)
 	UpdateAddress(ctx context.Context, in *UpdateAddressRequest, opts ...grpc.CallOption) (*UpdateAddressResponse, error)
+	// GetInstances returns all healthy instances of the service
+	GetInstances(ctx context.Context, in *GetInstancesRequest, opts ...grpc.CallOption) (*GetInstancesResponse, error)
+	// RegisterInstance adds or refreshes the instance, it is removed when ttl expires without a new registration
+	RegisterInstance(ctx context.Context, in *RegisterInstanceRequest, opts ...grpc.CallOption) (*RegisterInstanceResponse, error)
+	DeregisterInstance(ctx context.Context, in *DeregisterInstanceRequest, opts ...grpc.CallOption) (*DeregisterInstanceResponse, error)
+	// WatchAddresses sends healthy instances of the service and then every change of them
+	WatchAddresses(ctx context.Context, in *WatchAddressesRequest, opts ...grpc.CallOption) (DomainNameService_WatchAddressesClient, error)
 }
 
 type domainNameServiceClient struct {
@@ -57,12 +68,78 @@ func (c *domainNameServiceClient) UpdateAddress(ctx context.Context, in *UpdateA
 	return out, nil
 }
 
+func (c *domainNameServiceClient) GetInstances(ctx context.Context, in *GetInstancesRequest, opts ...grpc.CallOption) (*GetInstancesResponse, error) {
+	out := new(GetInstancesResponse)
+	err := c.cc.Invoke(ctx, DomainNameService_GetInstances_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *domainNameServiceClient) RegisterInstance(ctx context.Context, in *RegisterInstanceRequest, opts ...grpc.CallOption) (*RegisterInstanceResponse, error) {
+	out := new(RegisterInstanceResponse)
+	err := c.cc.Invoke(ctx, DomainNameService_RegisterInstance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *domainNameServiceClient) DeregisterInstance(ctx context.Context, in *DeregisterInstanceRequest, opts ...grpc.CallOption) (*DeregisterInstanceResponse, error) {
+	out := new(DeregisterInstanceResponse)
+	err := c.cc.Invoke(ctx, DomainNameService_DeregisterInstance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *domainNameServiceClient) WatchAddresses(ctx context.Context, in *WatchAddressesRequest, opts ...grpc.CallOption) (DomainNameService_WatchAddressesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DomainNameService_ServiceDesc.Streams[0], DomainNameService_WatchAddresses_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &domainNameServiceWatchAddressesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DomainNameService_WatchAddressesClient interface {
+	Recv() (*WatchAddressesResponse, error)
+	grpc.ClientStream
+}
+
+type domainNameServiceWatchAddressesClient struct {
+	grpc.ClientStream
+}
+
+func (x *domainNameServiceWatchAddressesClient) Recv() (*WatchAddressesResponse, error) {
+	m := new(WatchAddressesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // DomainNameServiceServer is the server API for DomainNameService service.
 // All implementations must embed UnimplementedDomainNameServiceServer
 // for forward compatibility
 type DomainNameServiceServer interface {
 	GetAddress(context.Context, *GetAddressRequest) (*GetAddressResponse, error)
 	UpdateAddress(context.Context, *UpdateAddressRequest) (*UpdateAddressResponse, error)
+	// GetInstances returns all healthy instances of the service
+	GetInstances(context.Context, *GetInstancesRequest) (*GetInstancesResponse, error)
+	// RegisterInstance adds or refreshes the instance, it is removed when ttl expires without a new registration
+	RegisterInstance(context.Context, *RegisterInstanceRequest) (*RegisterInstanceResponse, error)
+	DeregisterInstance(context.Context, *DeregisterInstanceRequest) (*DeregisterInstanceResponse, error)
+	// WatchAddresses sends healthy instances of the service and then every change of them
+	WatchAddresses(*WatchAddressesRequest, DomainNameService_WatchAddressesServer) error
 	mustEmbedUnimplementedDomainNameServiceServer()
 }
 
@@ -76,6 +153,18 @@ func (UnimplementedDomainNameServiceServer) GetAddress(context.Context, *GetAddr
 func (UnimplementedDomainNameServiceServer) UpdateAddress(context.Context, *UpdateAddressRequest) (*UpdateAddressResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateAddress not implemented")
 }
+func (UnimplementedDomainNameServiceServer) GetInstances(context.Context, *GetInstancesRequest) (*GetInstancesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInstances not implemented")
+}
+func (UnimplementedDomainNameServiceServer) RegisterInstance(context.Context, *RegisterInstanceRequest) (*RegisterInstanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterInstance not implemented")
+}
+func (UnimplementedDomainNameServiceServer) DeregisterInstance(context.Context, *DeregisterInstanceRequest) (*DeregisterInstanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeregisterInstance not implemented")
+}
+func (UnimplementedDomainNameServiceServer) WatchAddresses(*WatchAddressesRequest, DomainNameService_WatchAddressesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchAddresses not implemented")
+}
 func (UnimplementedDomainNameServiceServer) mustEmbedUnimplementedDomainNameServiceServer() {}
 
 // UnsafeDomainNameServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -125,6 +214,81 @@ func _DomainNameService_UpdateAddress_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DomainNameService_GetInstances_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetInstancesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DomainNameServiceServer).GetInstances(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DomainNameService_GetInstances_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DomainNameServiceServer).GetInstances(ctx, req.(*GetInstancesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DomainNameService_RegisterInstance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterInstanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DomainNameServiceServer).RegisterInstance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DomainNameService_RegisterInstance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DomainNameServiceServer).RegisterInstance(ctx, req.(*RegisterInstanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DomainNameService_DeregisterInstance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeregisterInstanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DomainNameServiceServer).DeregisterInstance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DomainNameService_DeregisterInstance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DomainNameServiceServer).DeregisterInstance(ctx, req.(*DeregisterInstanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DomainNameService_WatchAddresses_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchAddressesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DomainNameServiceServer).WatchAddresses(m, &domainNameServiceWatchAddressesServer{stream})
+}
+
+type DomainNameService_WatchAddressesServer interface {
+	Send(*WatchAddressesResponse) error
+	grpc.ServerStream
+}
+
+type domainNameServiceWatchAddressesServer struct {
+	grpc.ServerStream
+}
+
+func (x *domainNameServiceWatchAddressesServer) Send(m *WatchAddressesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // DomainNameService_ServiceDesc is the grpc.ServiceDesc for DomainNameService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -140,7 +304,25 @@ var DomainNameService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateAddress",
 			Handler:    _DomainNameService_UpdateAddress_Handler,
 		},
+		{
+			MethodName: "GetInstances",
+			Handler:    _DomainNameService_GetInstances_Handler,
+		},
+		{
+			MethodName: "RegisterInstance",
+			Handler:    _DomainNameService_RegisterInstance_Handler,
+		},
+		{
+			MethodName: "DeregisterInstance",
+			Handler:    _DomainNameService_DeregisterInstance_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchAddresses",
+			Handler:       _DomainNameService_WatchAddresses_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/domain-name-service/domain-name-service.proto",
 }